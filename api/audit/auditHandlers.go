@@ -0,0 +1,79 @@
+package audit
+
+import (
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+// getAuditFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get audit trail
+//	@Description	Requests audit events of all users. Needs the audit:read permission (admin and support roles). Dates are RFC 3339. Defaults: order=desc, count=50, page=1
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			actorId		query		string					false	"Actor UUID"
+//	@Param			entityKind	query		string					false	"list/task/user/session"
+//	@Param			entityId	query		string					false	"Entity UUID"
+//	@Param			listId		query		string					false	"List UUID"
+//	@Param			action		query		string					false	"create/update/delete"
+//	@Param			from		query		string					false	"From date"
+//	@Param			to			query		string					false	"To date"
+//	@Param			order		query		string					false	"asc/desc (default)"
+//	@Param			count		query		string					false	"Count (number of events to show per page)"
+//	@Param			page		query		string					false	"Page number"
+//	@Success		200			{array}		Event					"OK"
+//	@Success		204			{object}	service.DefaultResponse	"No Content"
+//	@Failure		400			{object}	service.errorResponse	"Bad request"
+//	@Failure		401			{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403			{object}	service.errorResponse	"Forbidden"
+//	@Failure		500			{object}	service.errorResponse	"Internal server error"
+//	@Router			/admin/audit [get]
+func getAuditFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		if !aUser.Can(types.PermAuditRead) {
+			w.WriteHeader(http.StatusForbidden)
+			log.WithFields(log.Fields{
+				"id": aUser.UserUUID,
+			}).Error(service.Forbidden)
+			service.ForbiddenResponse(w, "")
+			return
+		}
+
+		params, err := FilterParams(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		var e Event
+		events, err := e.ReadMany(s.DbWorker, params)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.AuditReadErr, err)
+			service.InternalServerErrorResponse(w, service.AuditReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.AuditReadSuccess)
+		service.OkResponse(w, events)
+	}
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"reflect"
+	"time"
+	"todoApp/api/service"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+
+	KindList    = "list"
+	KindTask    = "task"
+	KindUser    = "user"
+	KindSession = "session"
+)
+
+type Event struct {
+	ID         uint      `json:"-" gorm:"primarykey"`
+	EventUUID  uuid.UUID `json:"id" gorm:"index" extensions:"x-order=1"`
+	ActorUUID  uuid.UUID `json:"actorId" gorm:"index" extensions:"x-order=2"`
+	EntityKind string    `json:"entityKind" gorm:"index" extensions:"x-order=3"`
+	EntityUUID uuid.UUID `json:"entityId" gorm:"index" extensions:"x-order=4"`
+	ListUUID   uuid.UUID `json:"listId" gorm:"index" extensions:"x-order=5"`
+	Action     string    `json:"action" gorm:"index" extensions:"x-order=6"`
+	Changes    string    `json:"changes" extensions:"x-order=7"`
+	ClientIP   string    `json:"clientIp" extensions:"x-order=8"`
+	ClientInfo string    `json:"clientInfo" extensions:"x-order=9"`
+	CreatedAt  time.Time `json:"timestamp" gorm:"index" extensions:"x-order=10"`
+}
+
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Record stores an audit event for the request. Before and after are the
+// entity states around the change, either of them may be nil. A failure to
+// write the event is only logged, the main action has already happened.
+func Record(dbw dbWorker, r *http.Request, e Event, before, after any) {
+	e.EventUUID = uuid.New()
+	e.ClientIP = service.ClientIP(r, proxyHeader)
+	e.ClientInfo = r.UserAgent()
+
+	bytes, err := service.SerializeJSON(Diff(before, after))
+	if err != nil {
+		log.Error(service.JSONSerializingErr, err)
+		return
+	}
+	e.Changes = string(bytes)
+
+	err = dbw.CreateRecord(&e)
+	if err != nil {
+		log.Error(service.AuditCreateErr, err)
+	}
+}
+
+// Diff compares JSON representations of two entity states and returns only
+// the fields that differ.
+func Diff(before, after any) map[string]Change {
+	b := toFields(before)
+	a := toFields(after)
+	changes := make(map[string]Change)
+
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changes[k] = Change{Before: v, After: a[k]}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{Before: nil, After: v}
+		}
+	}
+	return changes
+}
+
+func toFields(v any) map[string]any {
+	fields := make(map[string]any)
+	if v == nil {
+		return fields
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return fields
+	}
+
+	bytes, err := service.SerializeJSON(v)
+	if err != nil {
+		return fields
+	}
+	err = service.DeserializeJSON(bytes, &fields)
+	if err != nil {
+		return fields
+	}
+	delete(fields, "password")
+	return fields
+}
+
+func (e *Event) ReadMany(dbw dbWorker, params map[string]any) ([]Event, error) {
+	var events []Event
+	err := dbw.ReadWithPagination(&events, params)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"todoApp/api/service"
+)
+
+// isAuth answers the request itself when authentication or the token scope
+// check fails, callers only have to return.
+func (a *authUser) isAuth(w http.ResponseWriter, r *http.Request, s *Service) error {
+	token, err := service.ReadToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.TokenReadErr, err.Error())
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	authUsr, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.AuthErr, err)
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if !authUsr.Allows("", write) {
+		w.WriteHeader(http.StatusForbidden)
+		log.Error(service.TokenScopeErr)
+		service.ForbiddenResponse(w, service.TokenScopeErr)
+		return errors.New(service.TokenScopeErr)
+	}
+
+	a.AuthUser = authUsr
+	return nil
+}
+
+// FilterParams converts audit query parameters into DatabaseWorker params.
+// Supported: actorId, entityKind, entityId, listId, action, from, to (RFC 3339),
+// order, count and page.
+func FilterParams(q url.Values) (map[string]any, error) {
+	params := map[string]any{
+		"order":   validateOrder(q.Get("order")),
+		"sort_by": "created_at",
+		"count":   validateQueryInt(q.Get("count"), 50),
+		"page":    validateQueryInt(q.Get("page"), 1),
+	}
+
+	uuids := map[string]string{"actorId": "actor_uuid", "entityId": "entity_uuid", "listId": "list_uuid"}
+	for query, column := range uuids {
+		if q.Get(query) == "" {
+			continue
+		}
+		id, err := uuid.Parse(q.Get(query))
+		if err != nil {
+			return nil, err
+		}
+		params[column] = id
+	}
+
+	if kind := q.Get("entityKind"); kind != "" {
+		params["entity_kind"] = kind
+	}
+	if action := q.Get("action"); action != "" {
+		params["action"] = action
+	}
+
+	if from := q.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, err
+		}
+		params["created_at >="] = t
+	}
+	if to := q.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, err
+		}
+		params["created_at <="] = t
+	}
+
+	return params, nil
+}
+
+func validateQueryInt(queryValue string, defaultValue int) int {
+	i, err := strconv.Atoi(queryValue)
+	if err != nil {
+		return defaultValue
+	}
+	if i <= 0 {
+		return defaultValue
+	}
+	return i
+}
+
+func validateOrder(order string) string {
+	switch order {
+	case "asc":
+		return "asc"
+	default:
+		return "desc"
+	}
+}
@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+	"todoApp/api/service"
+	"todoApp/config"
+	"todoApp/types"
+)
+
+type (
+	dbWorker types.DatabaseWorker
+	authUser struct{ types.AuthUser }
+)
+
+type Service struct {
+	DbWorker   types.DatabaseWorker
+	AuthWorker types.AuthWorker
+	Router     *http.ServeMux
+	Config     *config.Config
+}
+
+// proxyHeader is the TRUSTED_PROXY_HEADER events take the client address
+// from, see service.ClientIP.
+var proxyHeader string
+
+func Init(s *Service) {
+	proxyHeader = s.Config.Config.TrustedProxyHeader
+
+	err := s.DbWorker.InitTable(&Event{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	addRoutes(s)
+}
@@ -0,0 +1,6 @@
+package audit
+
+func addRoutes(s *Service) {
+	getAuditHandler := getAuditFunc(s)
+	s.Router.HandleFunc("GET /api/v1/admin/audit", getAuditHandler)
+}
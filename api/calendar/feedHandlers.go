@@ -0,0 +1,303 @@
+package calendar
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"todoApp/api/service"
+	"todoApp/api/todoList"
+	"todoApp/api/user"
+)
+
+// createTokenFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Create calendar feed token
+//	@Description	Creates a token for the iCalendar feed. The plain token is shown only once.
+//	@Tags			Calendar
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		createFeedToken			true	"Token name"
+//	@Success		200		{object}	newFeedToken			"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/calendar/tokens [post]
+func createTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createFeedToken{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		token, err := generateFeedToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.FeedTokenCreateErr, err)
+			service.InternalServerErrorResponse(w, service.FeedTokenCreateErr, err)
+			return
+		}
+
+		f := FeedToken{
+			TokenUUID: uuid.New(),
+			OwnerUUID: aUser.UserUUID,
+			Name:      c.Name,
+			TokenHash: hashToken(token),
+		}
+
+		err = f.Create(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.FeedTokenCreateErr, err)
+			service.InternalServerErrorResponse(w, service.FeedTokenCreateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":   f.TokenUUID,
+			"name": f.Name,
+		}).Info(service.FeedTokenCreateSuccess)
+
+		service.OkResponse(w, newFeedToken{
+			TokenUUID: f.TokenUUID,
+			Name:      f.Name,
+			Token:     token,
+			URL:       fmt.Sprintf("%s/api/v1/calendar/%s.ics", s.Config.Config.ApiURL, token),
+		})
+	}
+}
+
+// getTokensFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get calendar feed tokens
+//	@Description	Requests all active feed tokens
+//	@Tags			Calendar
+//	@Produce		json
+//	@Success		200	{array}		FeedToken				"OK"
+//	@Success		204	{object}	service.DefaultResponse	"No Content"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/calendar/tokens [get]
+func getTokensFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		f := FeedToken{OwnerUUID: aUser.UserUUID}
+		tokens, err := f.ReadAll(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.FeedTokenReadErr, err)
+			service.InternalServerErrorResponse(w, service.FeedTokenReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.FeedTokenReadSuccess)
+		service.OkResponse(w, tokens)
+	}
+}
+
+// revokeTokenFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Revoke calendar feed token
+//	@Description	Revokes the feed token, subscribed calendars stop updating
+//	@Tags			Calendar
+//	@Produce		json
+//	@Param			tokenId	path		string					true	"Token UUID"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/calendar/tokens/{tokenId} [delete]
+func revokeTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("tokenId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		f := FeedToken{TokenUUID: id, OwnerUUID: aUser.UserUUID}
+		err = f.Delete(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.FeedTokenDeleteErr, err)
+			service.InternalServerErrorResponse(w, service.FeedTokenDeleteErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.FeedTokenDeleteSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.FeedTokenDeleteSuccess,
+			Data:       nil,
+		})
+	}
+}
+
+// feedFunc godoc
+//
+//	@Summary		iCalendar feed
+//	@Description	Tasks with start date or deadline as an RFC 5545 calendar. Authenticated by the feed token in the path, e.g. /calendar/{token}.ics
+//	@Tags			Calendar
+//	@Produce		text/calendar
+//	@Param			feed	path		string					true	"{token}.ics"
+//	@Param			listId	query		string					false	"Only tasks of this list"
+//	@Param			type	query		string					false	"todo (default)/event"
+//	@Success		200		{string}	string					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/calendar/{feed} [get]
+func feedFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutSuffix(r.PathValue("feed"), ".ics")
+		if !found || token == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.DBNotFound)
+			service.NotFoundResponse(w, "")
+			return
+		}
+
+		f := FeedToken{}
+		err := f.ReadByToken(s.DbWorker, token)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.InvalidTokenErr)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.FeedTokenReadErr, err)
+			service.InternalServerErrorResponse(w, service.FeedTokenReadErr, err)
+			return
+		}
+
+		params := map[string]any{"owner_uuid": f.OwnerUUID}
+		if listId := r.URL.Query().Get("listId"); listId != "" {
+			id, err := uuid.Parse(listId)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				log.Error(service.ParseErr, err)
+				service.BadRequestResponse(w, service.ParseErr, err)
+				return
+			}
+			params["todo_list_uuid"] = id
+		}
+
+		var tasks []todoList.Task
+		err = s.DbWorker.ReadManyRecords(todoList.Task{}, &tasks, params)
+		if err != nil && err.Error() != "404" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		name := "Tasks"
+		if f.Name != "" {
+			name = f.Name
+		}
+		prefs := user.UserSettings{UserUUID: f.OwnerUUID}
+		err = prefs.Read(s.DbWorker)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		asEvents := r.URL.Query().Get("type") == "event"
+		body := renderCalendar(name, uidDomain(s), prefs.Timezone, tasks, asEvents, time.Now())
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+		w.WriteHeader(http.StatusOK)
+
+		_, err = io.WriteString(w, body)
+		if err != nil {
+			log.WithFields(log.Fields{service.WriteBytesErr: err}).Error(service.ServerResponseErr)
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"id":    f.TokenUUID,
+			"tasks": len(tasks),
+		}).Info(service.FeedReadSuccess)
+	}
+}
@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+type FeedToken struct {
+	gorm.Model `json:"-"`
+	TokenUUID  uuid.UUID `json:"id" gorm:"index" extensions:"x-order=1"`
+	OwnerUUID  uuid.UUID `json:"-" gorm:"index"`
+	Name       string    `json:"name" extensions:"x-order=2"`
+	TokenHash  string    `json:"-" gorm:"uniqueIndex"`
+	AddedDate  time.Time `json:"addedDate" gorm:"-" extensions:"x-order=3"`
+}
+
+type createFeedToken struct {
+	Name string `json:"name" example:"Work calendar"`
+}
+
+type newFeedToken struct {
+	TokenUUID uuid.UUID `json:"id" extensions:"x-order=1"`
+	Name      string    `json:"name" extensions:"x-order=2"`
+	Token     string    `json:"token" extensions:"x-order=3"`
+	URL       string    `json:"url" extensions:"x-order=4"`
+}
+
+func (c *createFeedToken) validate() error {
+	if len([]rune(c.Name)) > 100 {
+		return errors.New("name is too long (MAX=100)")
+	}
+	return nil
+}
+
+func (f *FeedToken) Create(dbw dbWorker) error {
+	err := dbw.CreateRecord(f)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadByToken finds a token by its plain value taken from the feed url.
+func (f *FeedToken) ReadByToken(dbw dbWorker, token string) error {
+	params := map[string]any{"token_hash": hashToken(token)}
+	err := dbw.ReadOneRecord(f, params)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (f *FeedToken) ReadAll(dbw dbWorker) ([]FeedToken, error) {
+	var tokens []FeedToken
+	params := map[string]any{"owner_uuid": f.OwnerUUID, "order": "desc", "sort_by": "created_at"}
+	err := dbw.ReadManyRecords(FeedToken{}, &tokens, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tokens {
+		tokens[i].AddedDate = tokens[i].CreatedAt
+	}
+	return tokens, nil
+}
+
+func (f *FeedToken) Delete(dbw dbWorker) error {
+	params := map[string]any{"token_uuid": f.TokenUUID, "owner_uuid": f.OwnerUUID}
+	err := dbw.DeleteRecord(f, params)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"todoApp/api/service"
+)
+
+// isAuth answers the request itself when authentication or the token scope
+// check fails, callers only have to return.
+func (a *authUser) isAuth(w http.ResponseWriter, r *http.Request, s *Service) error {
+	token, err := service.ReadToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.TokenReadErr, err.Error())
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	authUsr, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.AuthErr, err)
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if !authUsr.Allows("", write) {
+		w.WriteHeader(http.StatusForbidden)
+		log.Error(service.TokenScopeErr)
+		service.ForbiddenResponse(w, service.TokenScopeErr)
+		return errors.New(service.TokenScopeErr)
+	}
+
+	a.AuthUser = authUsr
+	return nil
+}
+
+func generateFeedToken() (string, error) {
+	bytes := make([]byte, 32)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken is used to store feed tokens, only the subscriber knows the
+// plain value.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// uidDomain is the right-hand side of entry UIDs. It has to be globally
+// unique, so it is the public host of the API rather than the address the
+// server listens on.
+func uidDomain(s *Service) string {
+	u, err := url.Parse(s.Config.Config.ApiURL)
+	if err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return s.Config.Config.HTTPHost
+}
@@ -0,0 +1,142 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"todoApp/api/todoList"
+)
+
+const (
+	icsTimeFormat = "20060102T150405Z"
+	icsLineLimit  = 75
+)
+
+// icsWriter builds an RFC 5545 calendar. Lines end with CRLF and are folded
+// at 75 octets.
+type icsWriter struct {
+	b strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	l := name + ":" + value
+	for len(l) > icsLineLimit {
+		cut := icsLineLimit
+		for cut > 0 && !isRuneStart(l[cut]) {
+			cut--
+		}
+		w.b.WriteString(l[:cut] + "\r\n")
+		l = " " + l[cut:]
+	}
+	w.b.WriteString(l + "\r\n")
+}
+
+func (w *icsWriter) time(name string, t time.Time) {
+	w.line(name, t.UTC().Format(icsTimeFormat))
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// icsStatus maps task status to VTODO STATUS.
+func icsStatus(status int) string {
+	switch status {
+	case todoList.TaskStatusInProgress:
+		return "IN-PROCESS"
+	case todoList.TaskStatusCompleted:
+		return "COMPLETED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// icsPriority maps task priority to the 1-9 scale where 1 is the highest;
+// 0 means undefined.
+func icsPriority(priority int) int {
+	switch priority {
+	case todoList.TaskPriorityLow:
+		return 9
+	case todoList.TaskPriorityMiddle:
+		return 5
+	case todoList.TaskPriorityHi:
+		return 3
+	case todoList.TaskPriorityUrgently:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// renderCalendar writes tasks as VTODO entries, or as VEVENT entries when
+// asEvents is set since many calendar apps ignore to-dos. Times are written
+// in UTC, X-WR-TIMEZONE tells clients the owner's time zone to show them in.
+// A deadline before the start date would make an invalid entry, events end
+// when they start then and to-dos drop the start.
+func renderCalendar(name, domain, timezone string, tasks []todoList.Task, asEvents bool, now time.Time) string {
+	w := icsWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//TodoApp//Tasks//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", escapeText(name))
+	w.line("X-WR-TIMEZONE", timezone)
+
+	for _, t := range tasks {
+		if t.StartDate == nil && t.Deadline == nil {
+			continue
+		}
+
+		component := "VTODO"
+		if asEvents {
+			component = "VEVENT"
+		}
+
+		w.line("BEGIN", component)
+		w.line("UID", fmt.Sprintf("%s@%s", t.TaskUUID, domain))
+		w.time("DTSTAMP", now)
+		w.time("LAST-MODIFIED", t.UpdatedAt)
+		w.line("SUMMARY", escapeText(t.Title))
+		if t.Description != "" {
+			w.line("DESCRIPTION", escapeText(t.Description))
+		}
+
+		if asEvents {
+			start, end := t.StartDate, t.Deadline
+			if start == nil {
+				start = end
+			}
+			if end == nil || end.Before(*start) {
+				end = start
+			}
+			w.time("DTSTART", *start)
+			w.time("DTEND", *end)
+		} else {
+			// DUE can't be before DTSTART, the deadline is what matters then.
+			if t.StartDate != nil && (t.Deadline == nil || !t.Deadline.Before(*t.StartDate)) {
+				w.time("DTSTART", *t.StartDate)
+			}
+			if t.Deadline != nil {
+				w.time("DUE", *t.Deadline)
+			}
+			w.line("STATUS", icsStatus(t.Status))
+			if t.CompletedAt != nil {
+				w.time("COMPLETED", *t.CompletedAt)
+			}
+		}
+
+		if p := icsPriority(t.Priority); p > 0 {
+			w.line("PRIORITY", fmt.Sprint(p))
+		}
+		w.line("END", component)
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.b.String()
+}
@@ -0,0 +1,30 @@
+package calendar
+
+import (
+	"log"
+	"net/http"
+	"todoApp/api/service"
+	"todoApp/config"
+	"todoApp/types"
+)
+
+type (
+	dbWorker types.DatabaseWorker
+	authUser struct{ types.AuthUser }
+)
+
+type Service struct {
+	DbWorker   types.DatabaseWorker
+	AuthWorker types.AuthWorker
+	Router     *http.ServeMux
+	Config     *config.Config
+}
+
+func Init(s *Service) {
+	err := s.DbWorker.InitTable(&FeedToken{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	addRoutes(s)
+}
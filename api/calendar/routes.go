@@ -0,0 +1,15 @@
+package calendar
+
+func addRoutes(s *Service) {
+	createTokenHandler := createTokenFunc(s)
+	s.Router.HandleFunc("POST /api/v1/calendar/tokens", createTokenHandler)
+
+	getTokensHandler := getTokensFunc(s)
+	s.Router.HandleFunc("GET /api/v1/calendar/tokens", getTokensHandler)
+
+	revokeTokenHandler := revokeTokenFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/calendar/tokens/{tokenId}", revokeTokenHandler)
+
+	feedHandler := feedFunc(s)
+	s.Router.HandleFunc("GET /api/v1/calendar/{feed}", feedHandler)
+}
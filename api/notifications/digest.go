@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"todoApp/api/service"
+	"todoApp/api/todoList"
+	"todoApp/api/user"
+)
+
+type digestItem struct {
+	Title    string
+	List     string
+	Deadline string
+}
+
+type digestEmail struct {
+	Period          string
+	DueToday        []digestItem
+	Overdue         []digestItem
+	Completed       []digestItem
+	UnsubscribeLink string
+}
+
+func digestPeriod(frequency string) time.Duration {
+	if frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func runDigests(s *Service) {
+	interval := parseDuration(s.Config.Config.DigestInterval, time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sendDigests(s, time.Now())
+	}
+}
+
+func sendDigests(s *Service, now time.Time) {
+	var settings []Settings
+	params := map[string]any{"digest_frequency IN": []string{DigestDaily, DigestWeekly}}
+	err := s.DbWorker.ReadManyRecords(Settings{}, &settings, params)
+	if err != nil {
+		if err.Error() != "404" {
+			log.Error(service.SettingsReadErr, err)
+		}
+		return
+	}
+
+	for _, st := range settings {
+		if st.DigestLastSent != nil && now.Sub(*st.DigestLastSent) < digestPeriod(st.DigestFrequency) {
+			continue
+		}
+
+		err = digestUser(s, st, now)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"id": st.UserUUID,
+			}).Error(service.DigestSendErr, err)
+		}
+	}
+}
+
+func digestUser(s *Service, st Settings, now time.Time) error {
+	usr := user.User{UserUUID: st.UserUUID}
+	err := usr.Read(s.DbWorker)
+	if err != nil {
+		return err
+	}
+	if !usr.EmailVerified {
+		return nil
+	}
+
+	titles, err := listTitles(s, st.UserUUID)
+	if err != nil {
+		return err
+	}
+
+	prefs := user.UserSettings{UserUUID: st.UserUUID}
+	err = prefs.Read(s.DbWorker)
+	if err != nil {
+		return err
+	}
+	loc, layout := prefs.Location(), prefs.DateLayout()+" 15:04"
+
+	local := now.In(loc)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.Add(24 * time.Hour)
+
+	email := digestEmail{
+		Period:          st.DigestFrequency,
+		UnsubscribeLink: fmt.Sprintf("%s/api/v1/digest/unsubscribe/%s", s.Config.Config.ApiURL, st.UnsubscribeToken),
+	}
+
+	var open []todoList.Task
+	params := map[string]any{
+		"owner_uuid": st.UserUUID,
+		"deadline <": dayEnd,
+		"status <>":  todoList.TaskStatusCompleted,
+	}
+	err = s.DbWorker.ReadManyRecords(todoList.Task{}, &open, params)
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+
+	for _, t := range open {
+		item := digestItem{Title: t.Title, List: titles[t.TodoListUUID], Deadline: t.Deadline.In(loc).Format(layout)}
+		if t.Deadline.Before(now) {
+			email.Overdue = append(email.Overdue, item)
+		} else {
+			email.DueToday = append(email.DueToday, item)
+		}
+	}
+
+	since := now.Add(-digestPeriod(st.DigestFrequency))
+	if st.DigestLastSent != nil {
+		since = *st.DigestLastSent
+	}
+
+	var completed []todoList.Task
+	params = map[string]any{
+		"owner_uuid":      st.UserUUID,
+		"completed_at >=": since,
+	}
+	err = s.DbWorker.ReadManyRecords(todoList.Task{}, &completed, params)
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+
+	for _, t := range completed {
+		email.Completed = append(email.Completed, digestItem{Title: t.Title, List: titles[t.TodoListUUID]})
+	}
+
+	if len(email.DueToday)+len(email.Overdue)+len(email.Completed) > 0 {
+		err = service.SendEmail(s.Config, usr.Email, service.DigestSubject, "static/digest.html", email)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"id":        st.UserUUID,
+			"dueToday":  len(email.DueToday),
+			"overdue":   len(email.Overdue),
+			"completed": len(email.Completed),
+		}).Info(service.DigestSent)
+	}
+
+	return s.DbWorker.UpdateRecord(&Settings{DigestLastSent: &now}, map[string]any{"user_uuid": st.UserUUID})
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+	"todoApp/api/service"
+)
+
+// isAuth answers the request itself when authentication or the token scope
+// check fails, callers only have to return.
+func (a *authUser) isAuth(w http.ResponseWriter, r *http.Request, s *Service) error {
+	token, err := service.ReadToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.TokenReadErr, err.Error())
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	authUsr, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.AuthErr, err)
+		service.UnauthorizedResponse(w, "")
+		return err
+	}
+
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if !authUsr.Allows("", write) {
+		w.WriteHeader(http.StatusForbidden)
+		log.Error(service.TokenScopeErr)
+		service.ForbiddenResponse(w, service.TokenScopeErr)
+		return errors.New(service.TokenScopeErr)
+	}
+
+	a.AuthUser = authUsr
+	return nil
+}
+
+// parseDuration reads a Go duration from the config and falls back to
+// defaultValue when it is empty or malformed.
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+func generateKey() (string, error) {
+	bytes := make([]byte, 16)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"log"
+	"net/http"
+	"todoApp/api/service"
+	"todoApp/config"
+	"todoApp/types"
+)
+
+type (
+	dbWorker types.DatabaseWorker
+	authUser struct{ types.AuthUser }
+)
+
+type Service struct {
+	DbWorker   types.DatabaseWorker
+	AuthWorker types.AuthWorker
+	Router     *http.ServeMux
+	Config     *config.Config
+}
+
+func Init(s *Service) {
+	var err error
+
+	err = s.DbWorker.InitTable(&Settings{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&SentReminder{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	addRoutes(s)
+
+	go runReminders(s)
+	go runDigests(s)
+}
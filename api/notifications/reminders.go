@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"todoApp/api/service"
+	"todoApp/api/todoList"
+	"todoApp/api/user"
+)
+
+const (
+	reminderUpcoming = "upcoming"
+	reminderOverdue  = "overdue"
+)
+
+// SentReminder marks a reminder as delivered. A new one is sent only if the
+// task deadline changes. The row is claimed before the email goes out, so
+// a reminder is never sent twice.
+type SentReminder struct {
+	ID        uint      `gorm:"primarykey"`
+	TaskUUID  uuid.UUID `gorm:"uniqueIndex:idx_sent_reminder"`
+	Kind      string    `gorm:"uniqueIndex:idx_sent_reminder"`
+	Deadline  time.Time `gorm:"uniqueIndex:idx_sent_reminder"`
+	CreatedAt time.Time
+}
+
+type reminderItem struct {
+	Title    string
+	List     string
+	Deadline string
+}
+
+type reminderEmail struct {
+	Upcoming []reminderItem
+	Overdue  []reminderItem
+}
+
+func defaultLeadTime(s *Service) int {
+	return int(parseDuration(s.Config.Config.ReminderLeadTime, 24*time.Hour).Minutes())
+}
+
+func runReminders(s *Service) {
+	interval := parseDuration(s.Config.Config.ReminderInterval, 5*time.Minute)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sendReminders(s, time.Now())
+	}
+}
+
+func sendReminders(s *Service, now time.Time) {
+	var settings []Settings
+	params := map[string]any{"reminders_enabled": true}
+	err := s.DbWorker.ReadManyRecords(Settings{}, &settings, params)
+	if err != nil {
+		if err.Error() != "404" {
+			log.Error(service.SettingsReadErr, err)
+		}
+		return
+	}
+
+	for _, st := range settings {
+		err = remindUser(s, st, now)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"id": st.UserUUID,
+			}).Error(service.ReminderSendErr, err)
+		}
+	}
+}
+
+func remindUser(s *Service, st Settings, now time.Time) error {
+	usr := user.User{UserUUID: st.UserUUID}
+	err := usr.Read(s.DbWorker)
+	if err != nil {
+		return err
+	}
+	if !usr.EmailVerified {
+		return nil
+	}
+
+	var tasks []todoList.Task
+	params := map[string]any{
+		"owner_uuid":  st.UserUUID,
+		"deadline <=": now.Add(time.Duration(st.ReminderLeadTime) * time.Minute),
+		"status <>":   todoList.TaskStatusCompleted,
+	}
+	err = s.DbWorker.ReadManyRecords(todoList.Task{}, &tasks, params)
+	if err != nil {
+		if err.Error() == "404" {
+			return nil
+		}
+		return err
+	}
+
+	titles, err := listTitles(s, st.UserUUID)
+	if err != nil {
+		return err
+	}
+
+	prefs := user.UserSettings{UserUUID: st.UserUUID}
+	err = prefs.Read(s.DbWorker)
+	if err != nil {
+		return err
+	}
+	loc, layout := prefs.Location(), prefs.DateLayout()+" 15:04"
+
+	var email reminderEmail
+	var claimed []uint
+	for _, t := range tasks {
+		kind := reminderUpcoming
+		if t.Deadline.Before(now) {
+			kind = reminderOverdue
+		}
+
+		sent := SentReminder{TaskUUID: t.TaskUUID, Kind: kind, Deadline: *t.Deadline}
+		err = s.DbWorker.CreateIfMissing(&sent)
+		if err != nil {
+			if err.Error() == "409" {
+				continue
+			}
+			releaseReminders(s, claimed)
+			return err
+		}
+		claimed = append(claimed, sent.ID)
+
+		item := reminderItem{
+			Title:    t.Title,
+			List:     titles[t.TodoListUUID],
+			Deadline: t.Deadline.In(loc).Format(layout),
+		}
+		if kind == reminderOverdue {
+			email.Overdue = append(email.Overdue, item)
+		} else {
+			email.Upcoming = append(email.Upcoming, item)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	err = service.SendEmail(s.Config, usr.Email, service.ReminderSubject, "static/deadlineReminder.html", email)
+	if err != nil {
+		releaseReminders(s, claimed)
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"id":       st.UserUUID,
+		"upcoming": len(email.Upcoming),
+		"overdue":  len(email.Overdue),
+	}).Info(service.ReminderSent)
+	return nil
+}
+
+// releaseReminders gives back reminders that were claimed but not sent, the
+// next tick tries them again.
+func releaseReminders(s *Service, ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	err := s.DbWorker.DeleteRecord(&SentReminder{}, map[string]any{"id IN": ids})
+	if err != nil {
+		log.Error(service.ReminderSendErr, err)
+	}
+}
+
+func listTitles(s *Service, owner uuid.UUID) (map[uuid.UUID]string, error) {
+	var lists []todoList.TodoList
+	titles := make(map[uuid.UUID]string)
+
+	err := s.DbWorker.ReadManyRecords(todoList.TodoList{}, &lists, map[string]any{"owner_uuid": owner})
+	if err != nil {
+		if err.Error() == "404" {
+			return titles, nil
+		}
+		return nil, err
+	}
+
+	for _, l := range lists {
+		titles[l.ListUuid] = l.Title
+	}
+	return titles, nil
+}
@@ -0,0 +1,21 @@
+package notifications
+
+func addRoutes(s *Service) {
+	getRemindersHandler := getRemindersFunc(s)
+	s.Router.HandleFunc("GET /api/v1/me/reminders", getRemindersHandler)
+
+	updateRemindersHandler := updateRemindersFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/me/reminders", updateRemindersHandler)
+
+	getDigestHandler := getDigestFunc(s)
+	s.Router.HandleFunc("GET /api/v1/me/digest", getDigestHandler)
+
+	updateDigestHandler := updateDigestFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/me/digest", updateDigestHandler)
+
+	unsubscribePageHandler := unsubscribePageFunc(s)
+	s.Router.HandleFunc("GET /api/v1/digest/unsubscribe/{token}", unsubscribePageHandler)
+
+	unsubscribeHandler := unsubscribeFunc(s)
+	s.Router.HandleFunc("POST /api/v1/digest/unsubscribe/{token}", unsubscribeHandler)
+}
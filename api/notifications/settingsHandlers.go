@@ -0,0 +1,341 @@
+package notifications
+
+import (
+	log "github.com/sirupsen/logrus"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"todoApp/api/service"
+)
+
+// getRemindersFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get reminder settings
+//	@Description	Requests deadline reminder settings. Lead time is in minutes.
+//	@Tags			Notifications
+//	@Produce		json
+//	@Success		200	{object}	Settings				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/reminders [get]
+func getRemindersFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		st := Settings{UserUUID: aUser.UserUUID}
+		err = st.Read(s.DbWorker, defaultLeadTime(s))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.SettingsReadSuccess)
+		service.OkResponse(w, st)
+	}
+}
+
+// updateRemindersFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Update reminder settings
+//	@Description	Opts in or out of deadline reminder emails. Lead time is in minutes, max 30 days.
+//	@Tags			Notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		updateReminders			true	"Reminder settings"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/reminders [put]
+func updateRemindersFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		upd := updateReminders{UserUUID: aUser.UserUUID, ReminderLeadTime: defaultLeadTime(s)}
+		err = service.DeserializeJSON(data, &upd)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = upd.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		err = upd.Save(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsUpdateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":       aUser.UserUUID,
+			"enabled":  upd.RemindersEnabled,
+			"leadTime": upd.ReminderLeadTime,
+		}).Info(service.SettingsUpdateSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.SettingsUpdateSuccess,
+			Data:       nil,
+		})
+	}
+}
+
+// getDigestFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get digest settings
+//	@Description	Requests digest email settings
+//	@Tags			Notifications
+//	@Produce		json
+//	@Success		200	{object}	Settings				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/digest [get]
+func getDigestFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		st := Settings{UserUUID: aUser.UserUUID}
+		err = st.Read(s.DbWorker, defaultLeadTime(s))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.SettingsReadSuccess)
+		service.OkResponse(w, st)
+	}
+}
+
+// updateDigestFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Update digest settings
+//	@Description	Subscribes to a daily or weekly digest of due, overdue and completed tasks. Use "off" to unsubscribe.
+//	@Tags			Notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		updateDigest			true	"off/daily/weekly"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/digest [put]
+func updateDigestFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		upd := updateDigest{UserUUID: aUser.UserUUID}
+		err = service.DeserializeJSON(data, &upd)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = upd.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		st := Settings{UserUUID: aUser.UserUUID}
+		err = st.Read(s.DbWorker, defaultLeadTime(s))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		upd.UnsubscribeToken = st.UnsubscribeToken
+		if upd.UnsubscribeToken == "" {
+			upd.UnsubscribeToken, err = generateKey()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				log.Error(service.UnsubscribeTokenErr, err)
+				service.InternalServerErrorResponse(w, service.UnsubscribeTokenErr, err)
+				return
+			}
+		}
+
+		err = upd.Save(s.DbWorker, defaultLeadTime(s))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsUpdateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":        aUser.UserUUID,
+			"frequency": upd.DigestFrequency,
+		}).Info(service.SettingsUpdateSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.SettingsUpdateSuccess,
+			Data:       nil,
+		})
+	}
+}
+
+// unsubscribePageFunc godoc
+//
+//	@Summary		Unsubscribe page
+//	@Description	Shows a page asking to confirm the unsubscription. The link with the token is sent in every digest email. Nothing changes on GET, mail scanners and link prefetchers open such links too.
+//	@Tags			Notifications
+//	@Produce		html
+//	@Param			token	path	string	true	"unsubscribe token"
+//	@Success		200		"OK"
+//	@Router			/digest/unsubscribe/{token} [get]
+func unsubscribePageFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderUnsubscribePage(w, unsubscribePage{Action: r.URL.Path})
+	}
+}
+
+type unsubscribePage struct {
+	Action string
+	Done   bool
+}
+
+func renderUnsubscribePage(w http.ResponseWriter, page unsubscribePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	tmpl, err := template.ParseFiles("static/digestUnsubscribe.html")
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	err = tmpl.Execute(w, page)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// unsubscribeFunc godoc
+//
+//	@Summary		Unsubscribe from digest
+//	@Description	Turns the digest off. Sent by the form of the unsubscribe page, browsers get a page back, other clients JSON.
+//	@Tags			Notifications
+//	@Produce		json
+//	@Param			token	path		string					true	"unsubscribe token"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/digest/unsubscribe/{token} [post]
+func unsubscribeFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		st := Settings{UnsubscribeToken: r.PathValue("token")}
+		if st.UnsubscribeToken == "" {
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.DBNotFound)
+			service.NotFoundResponse(w, "")
+			return
+		}
+
+		err := st.Unsubscribe(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsUpdateErr, err)
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"id": st.UserUUID,
+		}).Info(service.DigestUnsubscribed)
+
+		// A browser submitting the form wants a page, not JSON.
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			renderUnsubscribePage(w, unsubscribePage{Done: true})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.DigestUnsubscribed,
+			Data:       nil,
+		})
+	}
+}
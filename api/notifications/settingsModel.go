@@ -0,0 +1,116 @@
+package notifications
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+const maxReminderLeadTime = 30 * 24 * 60
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+type Settings struct {
+	gorm.Model       `json:"-"`
+	UserUUID         uuid.UUID  `json:"-" gorm:"uniqueIndex"`
+	RemindersEnabled bool       `json:"remindersEnabled" extensions:"x-order=1"`
+	ReminderLeadTime int        `json:"reminderLeadTime" extensions:"x-order=2"`
+	DigestFrequency  string     `json:"digestFrequency" extensions:"x-order=3"`
+	DigestLastSent   *time.Time `json:"digestLastSent" extensions:"x-order=4"`
+	UnsubscribeToken string     `json:"-" gorm:"index"`
+}
+
+type updateReminders struct {
+	UserUUID         uuid.UUID `json:"-"`
+	RemindersEnabled bool      `json:"remindersEnabled" extensions:"x-order=1"`
+	ReminderLeadTime int       `json:"reminderLeadTime" extensions:"x-order=2"`
+}
+
+// Read loads user's settings. Users without saved settings get the defaults,
+// so a 404 from the database is not an error here.
+func (st *Settings) Read(dbw dbWorker, defaultLeadTime int) error {
+	params := map[string]any{"user_uuid": st.UserUUID}
+	err := dbw.ReadOneRecord(st, params)
+	if err != nil {
+		if err.Error() == "404" {
+			st.ReminderLeadTime = defaultLeadTime
+			st.DigestFrequency = DigestOff
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+type updateDigest struct {
+	UserUUID         uuid.UUID `json:"-"`
+	DigestFrequency  string    `json:"digestFrequency" example:"daily" extensions:"x-order=1"`
+	UnsubscribeToken string    `json:"-"`
+}
+
+func (u *updateReminders) validate() error {
+	if u.ReminderLeadTime < 0 || u.ReminderLeadTime > maxReminderLeadTime {
+		return errors.New("reminderLeadTime has to be between 0 and 43200 minutes")
+	}
+	return nil
+}
+
+func (u *updateReminders) Save(dbw dbWorker) error {
+	st := Settings{UserUUID: u.UserUUID}
+	err := dbw.ReadOneRecord(&st, map[string]any{"user_uuid": u.UserUUID})
+	if err != nil {
+		if err.Error() != "404" {
+			return err
+		}
+		st.RemindersEnabled = u.RemindersEnabled
+		st.ReminderLeadTime = u.ReminderLeadTime
+		st.DigestFrequency = DigestOff
+		return dbw.CreateRecord(&st)
+	}
+
+	params := map[string]any{"user_uuid": u.UserUUID}
+	return dbw.UpdateRecordSubmodel(Settings{}, u, params)
+}
+
+func (u *updateDigest) validate() error {
+	switch u.DigestFrequency {
+	case DigestOff, DigestDaily, DigestWeekly:
+		return nil
+	default:
+		return errors.New("digestFrequency has to be one of: off, daily, weekly")
+	}
+}
+
+func (u *updateDigest) Save(dbw dbWorker, defaultLeadTime int) error {
+	st := Settings{UserUUID: u.UserUUID}
+	err := dbw.ReadOneRecord(&st, map[string]any{"user_uuid": u.UserUUID})
+	if err != nil {
+		if err.Error() != "404" {
+			return err
+		}
+		st.ReminderLeadTime = defaultLeadTime
+		st.DigestFrequency = u.DigestFrequency
+		st.UnsubscribeToken = u.UnsubscribeToken
+		return dbw.CreateRecord(&st)
+	}
+
+	params := map[string]any{"user_uuid": u.UserUUID}
+	return dbw.UpdateRecordSubmodel(Settings{}, u, params)
+}
+
+// Unsubscribe turns the digest off for whoever owns the token.
+func (st *Settings) Unsubscribe(dbw dbWorker) error {
+	params := map[string]any{"unsubscribe_token": st.UnsubscribeToken}
+	err := dbw.ReadOneRecord(st, params)
+	if err != nil {
+		return err
+	}
+
+	upd := updateDigest{UserUUID: st.UserUUID, DigestFrequency: DigestOff, UnsubscribeToken: st.UnsubscribeToken}
+	return dbw.UpdateRecordSubmodel(Settings{}, &upd, map[string]any{"user_uuid": st.UserUUID})
+}
@@ -47,6 +47,17 @@ func BadRequestResponse(w http.ResponseWriter, errType string, errMsg any) {
 	})
 }
 
+// BadRequestDetailsResponse is BadRequestResponse for structured error
+// details that should reach the client as JSON rather than a string.
+func BadRequestDetailsResponse(w http.ResponseWriter, errType string, details any) {
+	serverResponse(w, errorResponse{
+		ResultCode: 1,
+		HttpCode:   http.StatusBadRequest,
+		Messages:   errType,
+		Data:       details,
+	})
+}
+
 func UnauthorizedResponse(w http.ResponseWriter, msg any) {
 	serverResponse(w, errorResponse{
 		ResultCode: 1,
@@ -83,6 +94,24 @@ func ConflictResponse(w http.ResponseWriter, msg any) {
 	})
 }
 
+func TooManyRequestsResponse(w http.ResponseWriter, msg any) {
+	serverResponse(w, errorResponse{
+		ResultCode: 1,
+		HttpCode:   http.StatusTooManyRequests,
+		Messages:   "Too Many Requests",
+		Data:       msg,
+	})
+}
+
+func UnsupportedMediaTypeResponse(w http.ResponseWriter, msg any) {
+	serverResponse(w, errorResponse{
+		ResultCode: 1,
+		HttpCode:   http.StatusUnsupportedMediaType,
+		Messages:   "Unsupported Media Type",
+		Data:       msg,
+	})
+}
+
 func UnprocessableEntityResponse(w http.ResponseWriter, errType string, errMsg error) {
 	serverResponse(w, errorResponse{
 		ResultCode: 1,
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client, without a port. Behind a
+// reverse proxy RemoteAddr is the proxy itself, so header
+// (TRUSTED_PROXY_HEADER) names the one the proxy puts the client address in,
+// e.g. X-Real-IP or X-Forwarded-For, whose last entry is the one the proxy
+// added. Only set it when the proxy overwrites or appends to the header,
+// clients can send it too.
+func ClientIP(r *http.Request, header string) string {
+	if header != "" {
+		values := strings.Split(strings.Join(r.Header.Values(header), ","), ",")
+		if ip := strings.TrimSpace(values[len(values)-1]); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
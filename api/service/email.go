@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bytes"
+	"crypto/tls"
+	"gopkg.in/gomail.v2"
+	"html/template"
+	"todoApp/config"
+)
+
+// SendEmail renders an html template from static/ with data and sends it
+// using the mail server from the config.
+func SendEmail(c *config.Config, to, subject, templatePath string, data any) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", c.Config.EmailReply)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return err
+	}
+
+	m.SetBody("text/html", body.String())
+
+	d := gomail.NewDialer(
+		c.Config.EmailService,
+		25,
+		c.Config.EmailLogin,
+		c.Config.EmailPass)
+
+	d.TLSConfig = &tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ const (
 	ServerResponseErr = "Server response error "
 	WriteBytesErr     = "Error sending data "
 	Unauthorized      = "Unauthorized"
+	Forbidden         = "Forbidden"
 	NoContent         = "No content"
 
 	/* User Errors */
@@ -19,6 +20,10 @@ const (
 	UserDeleteErr      = "User delete error "
 	EmailErr           = "Email is incorrect "
 	PasswordErr        = "Password is incorrect "
+	LoginErr           = "Invalid email or password"
+	LoginLockedErr     = "Too many failed login attempts, try again later"
+	LoginThrottleErr   = "Login attempts tracking error "
+	HashPasswordErr    = "Password hashing error "
 	ConflictErr        = "Already exists "
 	VerificationKeyErr = "Can't generate verification key "
 
@@ -26,11 +31,22 @@ const (
 
 	SessionCreateErr   = "Session create error "
 	SessionCloseErr    = "Session close error "
+	TokenRevokeErr     = "Token revoke error "
 	CookieReadErr      = "Cookie read error "
 	InvalidTokenErr    = "Invalid token "
 	TokenReadErr       = "Error getting token "
 	TokenValidationErr = "Error validating token "
 	UUIDParseErr       = "Error parsing uuid "
+	AuthHeaderErr      = "Authorization header must be \"Bearer <token>\""
+	TokenScopeErr      = "Token scope doesn't allow this action"
+	PermissionErr      = "Your role doesn't allow this action"
+	RoleErr            = "Unknown role "
+	RoleAssignErr      = "Role assign error "
+	OwnRoleErr         = "You can't change your own role"
+	RoleAssignSuccess  = "Role assigned successfully"
+	AuthConfigErr      = "Auth config error "
+	JWTModeOffErr      = "Token refresh is available only in JWT mode"
+	RefreshReuseErr    = "Rotated refresh token reused, its login is revoked"
 
 	/* TODO Lists Errors */
 
@@ -38,6 +54,7 @@ const (
 	ListReadErr   = "List read error "
 	ListUpdateErr = "List update error "
 	ListDeleteErr = "List delete error "
+	ListArchived  = "List is archived "
 
 	/* TODO Tasks Errors */
 
@@ -45,6 +62,59 @@ const (
 	TaskReadErr   = "Task read error "
 	TaskUpdateErr = "Task update error "
 	TaskDeleteErr = "Task delete error "
+	BatchErr      = "Batch error "
+
+	/* History Errors */
+
+	HistoryReadErr    = "History read error "
+	HistoryRestoreErr = "History restore error "
+
+	/* Settings Errors */
+
+	SettingsReadErr   = "Settings read error "
+	SettingsUpdateErr = "Settings update error "
+
+	/* Calendar Errors */
+
+	FeedTokenCreateErr = "Feed token create error "
+	FeedTokenReadErr   = "Feed token read error "
+	FeedTokenDeleteErr = "Feed token delete error "
+
+	/* Export Errors */
+
+	ExportErr      = "Export error "
+	ImportErr      = "Import error "
+	ImportParseErr = "Import file parse error "
+
+	/* Views Errors */
+
+	ViewCreateErr = "View create error "
+	ViewReadErr   = "View read error "
+	ViewUpdateErr = "View update error "
+	ViewDeleteErr = "View delete error "
+
+	/* Board Errors */
+
+	BoardReadErr   = "Board read error "
+	BoardUpdateErr = "Board update error "
+	WipLimitErr    = "Column WIP limit reached "
+
+	/* Time tracking Errors */
+
+	TimeEntryCreateErr = "Time entry create error "
+	TimeEntryReadErr   = "Time entry read error "
+	TimeEntryUpdateErr = "Time entry update error "
+	TimeEntryDeleteErr = "Time entry delete error "
+	TimerRunningErr    = "Another timer is already running "
+
+	/* Stats Errors */
+
+	StatsReadErr = "Stats read error "
+
+	/* Audit Errors */
+
+	AuditCreateErr = "Audit event create error "
+	AuditReadErr   = "Audit read error "
 
 	/* JSON Errors */
 
@@ -77,13 +147,51 @@ const (
 	TodoListUpdateSuccess = "Todo list updated successfully"
 	TodoListDeleteSuccess = "Todo list deleted successfully"
 
+	TodoListArchiveSuccess   = "Todo list archived successfully"
+	TodoListUnarchiveSuccess = "Todo list unarchived successfully"
+
 	TaskCreateSuccess = "Task created successfully"
 	TaskReadSuccess   = "Task read successfully"
 	TaskUpdateSuccess = "Task updated successfully"
 	TaskDeleteSuccess = "Task deleted successfully"
+	BatchSuccess      = "Batch applied successfully"
+
+	HistoryReadSuccess    = "History read successfully"
+	HistoryRestoreSuccess = "Version restored successfully"
+
+	AuditReadSuccess = "Audit events read successfully"
+
+	BoardReadSuccess   = "Board read successfully"
+	BoardUpdateSuccess = "Board columns updated successfully"
+
+	TimeEntryCreateSuccess = "Time entry added successfully"
+	TimeEntryReadSuccess   = "Time entries read successfully"
+	TimeEntryDeleteSuccess = "Time entry deleted successfully"
+	TimerStartSuccess      = "Timer started"
+	TimerStopSuccess       = "Timer stopped"
+
+	StatsReadSuccess = "Stats read successfully"
+	ViewReadSuccess  = "View read successfully"
+
+	ViewCreateSuccess = "View created successfully"
+	ViewUpdateSuccess = "View updated successfully"
+	ViewDeleteSuccess = "View deleted successfully"
+
+	ExportSuccess       = "Data exported successfully"
+	ImportSuccess       = "Data imported successfully"
+	ImportDryRunSuccess = "Import checked, nothing created"
+
+	FeedTokenCreateSuccess = "Feed token created successfully"
+	FeedTokenReadSuccess   = "Feed tokens read successfully"
+	FeedTokenDeleteSuccess = "Feed token revoked successfully"
+	FeedReadSuccess        = "Calendar feed read successfully"
+
+	SettingsReadSuccess   = "Settings read successfully"
+	SettingsUpdateSuccess = "Settings updated successfully"
 
 	SessionsReadSuccess  = "Sessions read successfully"
 	SessionsCloseSuccess = "Sessions closed successfully"
+	TokenRefreshSuccess  = "Token refreshed successfully"
 
 	/* Service messages */
 
@@ -100,4 +208,72 @@ const (
 	VerificationKeySent  = "Verification key sent"
 	VerificationSuccess  = "Verification success"
 	VerificationExpired  = "Verification key expired"
+
+	PasswordResetSubject   = "Reset your password"
+	PasswordResetErr       = "Password reset error "
+	PasswordResetKeyErr    = "Reset key is invalid or expired"
+	PasswordResetLockedErr = "Too many password reset requests, try again later"
+	PasswordResetSent      = "If the email is registered, a reset link has been sent"
+	PasswordResetSuccess   = "Password reset successfully"
+
+	UnlockSubject = "Unlock your account"
+	UnlockErr     = "Account unlock error "
+	UnlockKeyErr  = "Unlock key is invalid"
+	UnlockSent    = "Unlock link sent"
+	UnlockSuccess = "Account unlocked successfully"
+
+	WrongPasswordErr        = "Current password is wrong"
+	PasswordPolicyErr       = "Password doesn't meet the policy"
+	PasswordPolicyConfigErr = "Password policy config error "
+	PasswordChangeErr       = "Password change error "
+	PasswordChangeSuccess   = "Password changed successfully"
+
+	TwoFactorErr        = "Two-factor authentication error "
+	TwoFactorCodeErr    = "Invalid two-factor code"
+	TwoFactorEnabledErr = "Two-factor authentication is already enabled"
+	ChallengeErr        = "Login challenge is invalid or expired"
+	TwoFactorRequired   = "Two-factor code required"
+	TwoFactorEnrolled   = "Two-factor secret created"
+	TwoFactorEnabled    = "Two-factor authentication enabled"
+	TwoFactorDisabled   = "Two-factor authentication disabled"
+
+	AccessTokenCreateErr     = "Access token create error "
+	AccessTokenReadErr       = "Access token read error "
+	AccessTokenDeleteErr     = "Access token delete error "
+	AccessTokenCreateSuccess = "Access token created successfully"
+	AccessTokenReadSuccess   = "Access tokens read successfully"
+	AccessTokenDeleteSuccess = "Access token revoked successfully"
+
+	OIDCProviderErr         = "Unknown login provider"
+	OIDCProviderUnavailable = "Login provider is unavailable"
+	OIDCLoginErr            = "External login error "
+	OIDCStateErr            = "Login state is invalid or expired"
+	OIDCEmailErr            = "Login provider didn't confirm the email"
+	OIDCLinkErr             = "Account with this email is not verified, log in with password and verify it first"
+	OIDCLoginSuccess        = "External login succeeded"
+
+	OAuthClientCreateErr     = "OAuth client create error "
+	OAuthClientReadErr       = "OAuth client read error "
+	OAuthClientDeleteErr     = "OAuth client delete error "
+	OAuthClientAuthErr       = "OAuth client authentication failed"
+	OAuthRequestErr          = "Invalid authorization request"
+	OAuthConsentErr          = "OAuth consent error "
+	OAuthContentTypeErr      = "Content-Type must be application/json"
+	OAuthTokenErr            = "OAuth token error "
+	OAuthClientCreateSuccess = "OAuth client registered successfully"
+	OAuthClientReadSuccess   = "OAuth clients read successfully"
+	OAuthClientDeleteSuccess = "OAuth client deleted successfully"
+	OAuthConsentGranted      = "OAuth access granted"
+	OAuthConsentDenied       = "OAuth access denied"
+	OAuthTokenSuccess        = "OAuth token issued"
+
+	ReminderSubject = "Task deadlines"
+	ReminderSendErr = "Error sending deadline reminder "
+	ReminderSent    = "Deadline reminder sent"
+
+	DigestSubject       = "Your tasks digest"
+	DigestSendErr       = "Error sending digest "
+	DigestSent          = "Digest sent"
+	DigestUnsubscribed  = "Unsubscribed from digest"
+	UnsubscribeTokenErr = "Can't generate unsubscribe token "
 )
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ReadToken returns the credentials sent with the request: the
+// "Authorization: Bearer" header if there is one, the session cookie otherwise.
+func ReadToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		cookie, err := r.Cookie(SessionTokenName)
+		if err != nil {
+			return "", err
+		}
+		return cookie.Value, nil
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", errors.New(AuthHeaderErr)
+	}
+	return token, nil
+}
@@ -0,0 +1,185 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+// errBatchFailed rolls back an atomic batch, details are in the results.
+var errBatchFailed = errors.New("batch operation failed")
+
+// batchTasksFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Batch task operations
+//	@Description	Applies create/update/delete operations to tasks of the list. With atomic=true all operations are applied in one transaction and the first failure rolls everything back, otherwise every operation gets its own result. Operations not run after a failure get httpCode 424.
+//	@Tags			Tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			listId	path		string					true	"list uuid"
+//	@Param			data	body		batchRequest			true	"Operations"
+//	@Success		200		{object}	batchResponse			"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/tasks/batch [post]
+func batchTasksFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		listId, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		batch := batchRequest{}
+		err = service.DeserializeJSON(data, &batch)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = batch.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		todoList := TodoList{ListUuid: listId, OwnerUuid: aUser.UserUUID}
+		err = todoList.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		if todoList.Status == ListStatusArchived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, listId)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
+		resp := batchResponse{Atomic: batch.Atomic, Results: make([]batchResult, len(batch.Operations))}
+		var changes []batchChange
+		failedCode := 0
+
+		run := func(dbw types.DatabaseWorker) error {
+			for i := range batch.Operations {
+				op := &batch.Operations[i]
+				resp.Results[i] = batchResult{Index: i, Op: op.Op, TaskID: op.TaskID, HttpCode: http.StatusFailedDependency}
+
+				if batch.Atomic && failedCode != 0 {
+					continue
+				}
+
+				change, code, err := op.apply(dbw, listId, aUser.UserUUID)
+				resp.Results[i].TaskID = op.TaskID
+				resp.Results[i].HttpCode = code
+				if err != nil {
+					resp.Results[i].Error = err.Error()
+					if batch.Atomic {
+						failedCode = code
+					}
+					continue
+				}
+				changes = append(changes, change)
+			}
+
+			if failedCode != 0 {
+				return errBatchFailed
+			}
+			return nil
+		}
+
+		if batch.Atomic {
+			err = s.DbWorker.Transaction(run)
+		} else {
+			err = run(s.DbWorker)
+		}
+
+		if err != nil {
+			if failedCode == 0 {
+				failedCode = http.StatusInternalServerError
+			}
+			for i := range resp.Results {
+				if resp.Results[i].HttpCode == http.StatusOK {
+					resp.Results[i].HttpCode = http.StatusFailedDependency
+				}
+			}
+
+			w.WriteHeader(failedCode)
+			log.WithFields(log.Fields{
+				"list id": listId,
+			}).Error(service.BatchErr, err)
+			service.OkResponse(w, service.DefaultResponse{
+				ResultCode: 1,
+				HttpCode:   failedCode,
+				Messages:   service.BatchErr,
+				Data:       resp,
+			})
+			return
+		}
+		resp.Applied = true
+
+		for _, c := range changes {
+			audit.Record(s.DbWorker, r, audit.Event{
+				ActorUUID:  aUser.UserUUID,
+				EntityKind: audit.KindTask,
+				EntityUUID: c.taskId,
+				ListUUID:   listId,
+				Action:     c.action,
+			}, c.before, c.after)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"list id":    listId,
+			"operations": len(batch.Operations),
+			"applied":    len(changes),
+		}).Info(service.BatchSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.BatchSuccess,
+			Data:       resp,
+		})
+	}
+}
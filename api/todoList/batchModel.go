@@ -0,0 +1,137 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"todoApp/api/audit"
+)
+
+const (
+	batchCreate = "create"
+	batchUpdate = "update"
+	batchDelete = "delete"
+
+	maxBatchSize = 500
+)
+
+type batchRequest struct {
+	Atomic     bool             `json:"atomic" extensions:"x-order=1"`
+	Operations []batchOperation `json:"operations" extensions:"x-order=2"`
+}
+
+type batchOperation struct {
+	Op     string     `json:"op" example:"update" extensions:"x-order=1"`
+	TaskID uuid.UUID  `json:"id" extensions:"x-order=2"`
+	Task   createTask `json:"task" extensions:"x-order=3"`
+}
+
+type batchResult struct {
+	Index    int       `json:"index" extensions:"x-order=1"`
+	Op       string    `json:"op" extensions:"x-order=2"`
+	TaskID   uuid.UUID `json:"id" extensions:"x-order=3"`
+	HttpCode int       `json:"httpCode" extensions:"x-order=4"`
+	Error    string    `json:"error,omitempty" extensions:"x-order=5"`
+}
+
+type batchResponse struct {
+	Atomic  bool          `json:"atomic" extensions:"x-order=1"`
+	Applied bool          `json:"applied" extensions:"x-order=2"`
+	Results []batchResult `json:"results" extensions:"x-order=3"`
+}
+
+// batchChange is kept for the audit trail, which is written only after the
+// batch is committed.
+type batchChange struct {
+	action string
+	taskId uuid.UUID
+	before *Task
+	after  *Task
+}
+
+func (b *batchRequest) validate() error {
+	if len(b.Operations) == 0 {
+		return errors.New("operations are required")
+	}
+	if len(b.Operations) > maxBatchSize {
+		return errors.New("too many operations (MAX=500)")
+	}
+	return nil
+}
+
+// apply runs one operation within list for owner. The returned code is the
+// HTTP status the same single request would get.
+func (o *batchOperation) apply(dbw dbWorker, listId, owner uuid.UUID) (batchChange, int, error) {
+	switch o.Op {
+	case batchCreate:
+		err := o.Task.validateTitle()
+		if err != nil {
+			return batchChange{}, http.StatusBadRequest, err
+		}
+
+		t := Task{
+			Description:  o.Task.Description,
+			Title:        o.Task.Title,
+			Status:       o.Task.Status,
+			Priority:     o.Task.Priority,
+			StartDate:    o.Task.StartDate,
+			Deadline:     o.Task.Deadline,
+			TaskUUID:     uuid.New(),
+			TodoListUUID: listId,
+			Order:        o.Task.Order,
+			OwnerUUID:    owner,
+		}
+		err = t.Create(dbw)
+		if err != nil {
+			return batchChange{}, http.StatusInternalServerError, err
+		}
+		o.TaskID = t.TaskUUID
+		return batchChange{action: audit.ActionCreate, taskId: t.TaskUUID, after: &t}, http.StatusOK, nil
+
+	case batchUpdate:
+		err := o.Task.validateTitle()
+		if err != nil {
+			return batchChange{}, http.StatusBadRequest, err
+		}
+
+		before := Task{TaskUUID: o.TaskID, TodoListUUID: listId, OwnerUUID: owner}
+		err = before.ReadOne(dbw)
+		if err != nil {
+			return batchChange{}, statusFor(err), err
+		}
+
+		o.Task.TaskUUID, o.Task.TodoListUUID, o.Task.OwnerUUID = o.TaskID, listId, owner
+		err = o.Task.Update(dbw)
+		if err != nil {
+			return batchChange{}, statusFor(err), err
+		}
+
+		after := Task{TaskUUID: o.TaskID, TodoListUUID: listId, OwnerUUID: owner}
+		err = after.ReadOne(dbw)
+		if err != nil {
+			return batchChange{}, statusFor(err), err
+		}
+		return batchChange{action: audit.ActionUpdate, taskId: o.TaskID, before: &before, after: &after}, http.StatusOK, nil
+
+	case batchDelete:
+		t := Task{TaskUUID: o.TaskID, TodoListUUID: listId, OwnerUUID: owner}
+		err := t.ReadOne(dbw)
+		if err == nil {
+			err = t.Delete(dbw)
+		}
+		if err != nil {
+			return batchChange{}, statusFor(err), err
+		}
+		return batchChange{action: audit.ActionDelete, taskId: o.TaskID, before: &t}, http.StatusOK, nil
+
+	default:
+		return batchChange{}, http.StatusBadRequest, errors.New("op has to be one of: create, update, delete")
+	}
+}
+
+func statusFor(err error) int {
+	if err.Error() == "404" {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
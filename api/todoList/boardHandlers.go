@@ -0,0 +1,311 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// getBoardFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get kanban board
+//	@Description	Requests tasks of the list grouped into columns by status. Tasks in a column are sorted by order.
+//	@Tags			Board
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Success		200		{object}	Board					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/board [get]
+func getBoardFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = todoList.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		board, err := readBoard(s.DbWorker, todoList)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.BoardReadErr, err)
+			service.InternalServerErrorResponse(w, service.BoardReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.BoardReadSuccess)
+		service.OkResponse(w, board)
+	}
+}
+
+// updateBoardColumnsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Update board columns
+//	@Description	Sets names and WIP limits of the list's board columns. Columns are identified by task status, wipLimit=0 means no limit. Columns not sent stay as they are.
+//	@Tags			Board
+//	@Accept			json
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Param			data	body		[]BoardColumn			true	"Columns"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/board/columns [put]
+func updateBoardColumnsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		var columns []BoardColumn
+		err = service.DeserializeJSON(data, &columns)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = validateColumns(columns)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = todoList.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		err = saveBoardColumns(s.DbWorker, id, columns)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.BoardUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.BoardUpdateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.BoardUpdateSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.BoardUpdateSuccess,
+			Data:       "",
+		})
+	}
+}
+
+// transitionTaskFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Move task on board
+//	@Description	Moves task to the column of the given status and puts it at the position (0 is the top) there. Fails with 409 if the target column reached its WIP limit.
+//	@Tags			Board
+//	@Accept			json
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Param			data	body		transitionTask			true	"Target column and position"
+//	@Success		200		{object}	Task					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		409		{object}	service.errorResponse	"Conflict"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/transition [post]
+func transitionTaskFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		taskId, err := uuid.Parse(r.PathValue("taskId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		to := transitionTask{}
+		err = service.DeserializeJSON(data, &to)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = to.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		before := Task{TaskUUID: taskId, OwnerUUID: aUser.UserUUID}
+		err = before.ReadByUUID(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		archived, err := listIsArchived(s.DbWorker, before.TodoListUUID, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+		if archived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, before.TodoListUUID)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
+		err = before.Transition(s.DbWorker, to)
+		if err != nil {
+			if errors.Is(err, errWipLimit) {
+				w.WriteHeader(http.StatusConflict)
+				log.Error(service.WipLimitErr, taskId)
+				service.ConflictResponse(w, service.WipLimitErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.TaskUpdateErr, err)
+			return
+		}
+
+		after := Task{TaskUUID: taskId, OwnerUUID: aUser.UserUUID}
+		err = after.ReadByUUID(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		tasks := []Task{after}
+		err = addTrackedTime(s.DbWorker, tasks)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryReadErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryReadErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindTask,
+			EntityUUID: taskId,
+			ListUUID:   after.TodoListUUID,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id": taskId,
+			"status":  to.Status,
+			"order":   after.Order,
+		}).Info(service.TaskUpdateSuccess)
+		service.OkResponse(w, tasks[0])
+	}
+}
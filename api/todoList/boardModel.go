@@ -0,0 +1,276 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"todoApp/types"
+)
+
+var errWipLimit = errors.New("column WIP limit reached")
+
+var defaultColumnNames = map[int]string{
+	TaskStatusNew:        "New",
+	TaskStatusInProgress: "In progress",
+	TaskStatusCompleted:  "Completed",
+	TaskStatusDraft:      "Draft",
+}
+
+// BoardColumn holds user's name and WIP limit of the column showing tasks
+// with the given status. Zero WIP limit means no limit.
+type BoardColumn struct {
+	gorm.Model `json:"-"`
+	ListUUID   uuid.UUID `json:"-" gorm:"index"`
+	Status     int       `json:"status" extensions:"x-order=1"`
+	Name       string    `json:"name" extensions:"x-order=2"`
+	WipLimit   int       `json:"wipLimit" extensions:"x-order=3"`
+}
+
+type boardColumnTasks struct {
+	BoardColumn
+	Tasks []Task `json:"tasks" extensions:"x-order=4"`
+}
+
+type Board struct {
+	List    TodoList           `json:"list"`
+	Columns []boardColumnTasks `json:"columns"`
+}
+
+type transitionTask struct {
+	Status   int `json:"status" example:"1" extensions:"x-order=1"`
+	Position int `json:"position" example:"0" extensions:"x-order=2"`
+}
+
+type taskOrderUpdate struct {
+	Order int
+}
+
+type columnUpdate struct {
+	Name     string
+	WipLimit int
+}
+
+func validColumnStatus(status int) bool {
+	return status >= TaskStatusNew && status <= TaskStatusDraft
+}
+
+func validateColumns(columns []BoardColumn) error {
+	for _, c := range columns {
+		if !validColumnStatus(c.Status) {
+			return errors.New("unknown column status")
+		}
+		err := validateTitle(c.Name, "column name")
+		if err != nil {
+			return err
+		}
+		if c.WipLimit < 0 {
+			return errors.New("wipLimit can't be negative")
+		}
+	}
+	return nil
+}
+
+func (t *transitionTask) validate() error {
+	if !validColumnStatus(t.Status) {
+		return errors.New("unknown status")
+	}
+	if t.Position < 0 {
+		return errors.New("position can't be negative")
+	}
+	return nil
+}
+
+// readBoardColumns returns columns of the list in status order. Columns the
+// user never changed get default names and no WIP limit.
+func readBoardColumns(dbw dbWorker, listId uuid.UUID) ([]BoardColumn, error) {
+	var saved []BoardColumn
+	err := dbw.ReadManyRecords(BoardColumn{}, &saved, map[string]any{"list_uuid": listId})
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+
+	columns := make([]BoardColumn, 0, len(defaultColumnNames))
+	for status := TaskStatusNew; status <= TaskStatusDraft; status++ {
+		column := BoardColumn{ListUUID: listId, Status: status, Name: defaultColumnNames[status]}
+		for _, c := range saved {
+			if c.Status == status {
+				column = c
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func saveBoardColumns(dbw dbWorker, listId uuid.UUID, columns []BoardColumn) error {
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		for _, c := range columns {
+			existing := BoardColumn{}
+			params := map[string]any{"list_uuid": listId, "status": c.Status}
+			err := tx.ReadOneRecord(&existing, params)
+			if err != nil {
+				if err.Error() != "404" {
+					return err
+				}
+				err = tx.CreateRecord(&BoardColumn{ListUUID: listId, Status: c.Status, Name: c.Name, WipLimit: c.WipLimit})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err = tx.UpdateRecordSubmodel(BoardColumn{}, &columnUpdate{Name: c.Name, WipLimit: c.WipLimit}, map[string]any{"id": existing.ID})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// columnTasks returns tasks of the list with the given status sorted by
+// their order.
+func columnTasks(dbw dbWorker, listId, owner uuid.UUID, status int) ([]Task, error) {
+	var tasks []Task
+	params := map[string]any{
+		"todo_list_uuid": listId,
+		"owner_uuid":     owner,
+		"status":         status,
+		"order":          "asc",
+		"sort_by":        "order",
+	}
+	err := dbw.ReadManyRecords(Task{}, &tasks, params)
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+
+	err = addTrackedTime(dbw, tasks)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func readBoard(dbw dbWorker, list TodoList) (Board, error) {
+	board := Board{List: list}
+	columns, err := readBoardColumns(dbw, list.ListUuid)
+	if err != nil {
+		return board, err
+	}
+
+	for _, c := range columns {
+		tasks, err := columnTasks(dbw, list.ListUuid, list.OwnerUuid, c.Status)
+		if err != nil {
+			return board, err
+		}
+		if tasks == nil {
+			tasks = []Task{}
+		}
+		board.Columns = append(board.Columns, boardColumnTasks{BoardColumn: c, Tasks: tasks})
+	}
+	return board, nil
+}
+
+// ReadByUUID loads the user's task without knowing its list.
+func (t *Task) ReadByUUID(dbw dbWorker) error {
+	params := map[string]any{
+		"task_uuid":  t.TaskUUID,
+		"owner_uuid": t.OwnerUUID,
+	}
+	return dbw.ReadOneRecord(t, params)
+}
+
+// Transition moves the task to the column of the given status and puts it
+// at the position there. Tasks of both columns are renumbered from zero.
+// Moving into a full column fails with errWipLimit. The list row stays
+// locked until the move is done, so parallel moves into the last free slot
+// of a column are counted one after the other.
+func (t *Task) Transition(dbw dbWorker, to transitionTask) error {
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		list := TodoList{}
+		err := tx.ReadOneRecord(&list, map[string]any{"list_uuid": t.TodoListUUID, "lock": true})
+		if err != nil {
+			return err
+		}
+
+		columns, err := readBoardColumns(tx, t.TodoListUUID)
+		if err != nil {
+			return err
+		}
+
+		target, err := columnTasks(tx, t.TodoListUUID, t.OwnerUUID, to.Status)
+		if err != nil {
+			return err
+		}
+		target = withoutTask(target, t.TaskUUID)
+
+		limit := columns[to.Status].WipLimit
+		if to.Status != t.Status && limit > 0 && len(target) >= limit {
+			return errWipLimit
+		}
+
+		position := min(to.Position, len(target))
+		target = append(target[:position], append([]Task{*t}, target[position:]...)...)
+
+		for i, task := range target {
+			if task.TaskUUID != t.TaskUUID {
+				err = setTaskOrder(tx, task, i)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			c := createTask{
+				Title:        t.Title,
+				Description:  t.Description,
+				Status:       to.Status,
+				Priority:     t.Priority,
+				Order:        i,
+				StartDate:    t.StartDate,
+				Deadline:     t.Deadline,
+				TodoListUUID: t.TodoListUUID,
+				TaskUUID:     t.TaskUUID,
+				OwnerUUID:    t.OwnerUUID,
+			}
+			err = c.Update(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		if to.Status == t.Status {
+			return nil
+		}
+
+		source, err := columnTasks(tx, t.TodoListUUID, t.OwnerUUID, t.Status)
+		if err != nil {
+			return err
+		}
+		for i, task := range source {
+			err = setTaskOrder(tx, task, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func withoutTask(tasks []Task, id uuid.UUID) []Task {
+	result := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		if t.TaskUUID != id {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func setTaskOrder(dbw dbWorker, t Task, order int) error {
+	if t.Order == order {
+		return nil
+	}
+	params := map[string]any{"task_uuid": t.TaskUUID, "owner_uuid": t.OwnerUUID}
+	return dbw.UpdateRecordSubmodel(Task{}, &taskOrderUpdate{Order: order}, params)
+}
@@ -0,0 +1,145 @@
+package todoList
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+	"todoApp/api/service"
+	"todoApp/api/user"
+)
+
+// exportFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Export data
+//	@Description	Streams all your lists and tasks. CSV has one row per task (lists without tasks get a row with empty task columns), Markdown renders lists as checkbox lists. Profile and sessions are added to json and md only.
+//	@Tags			Export
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		text/markdown
+//	@Param			format	query		string					false	"json (default)/csv/md"
+//	@Param			include	query		string					false	"Comma separated: profile,sessions"
+//	@Success		200		{string}	string					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/export [get]
+func exportFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		var contentType string
+		switch format {
+		case "", exportJSON:
+			format, contentType = exportJSON, "application/json"
+		case exportCSV:
+			contentType = "text/csv; charset=utf-8"
+		case exportMarkdown:
+			contentType = "text/markdown; charset=utf-8"
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			err = errors.New("format has to be one of: json, csv, md")
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		var profile *exportProfile
+		var sessions []user.Session
+		for _, part := range strings.Split(r.URL.Query().Get("include"), ",") {
+			switch strings.TrimSpace(part) {
+			case "profile":
+				usr := user.User{UserUUID: aUser.UserUUID}
+				err = usr.Read(s.DbWorker)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					log.Error(service.UserReadErr, err)
+					service.InternalServerErrorResponse(w, service.UserReadErr, err)
+					return
+				}
+				profile = &exportProfile{
+					UserUUID: usr.UserUUID,
+					Email:    usr.Email,
+					Username: usr.Username,
+					Name:     usr.Name,
+					Surname:  usr.Surname,
+				}
+
+			case "sessions":
+				session := user.Session{UserUuid: aUser.UserUUID}
+				sessions, err = session.ReadAll(s.DbWorker)
+				if err != nil && err.Error() != "404" {
+					w.WriteHeader(http.StatusInternalServerError)
+					log.Error(service.DBReadErr, err)
+					service.InternalServerErrorResponse(w, service.DBReadErr, err)
+					return
+				}
+				if sessions == nil {
+					sessions = []user.Session{}
+				}
+			}
+		}
+
+		var rtl readTodoList
+		lists, err := rtl.GetAllLists(s.DbWorker, aUser, "asc", true)
+		if err != nil && err.Error() != "404" {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		filename := fmt.Sprintf("todo-export-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+
+		// Headers are sent, from here on errors can only be logged.
+		e := newExporter(format, w)
+		err = e.begin()
+		if err != nil {
+			log.Error(service.ExportErr, err)
+			return
+		}
+
+		for _, l := range lists {
+			t := Task{TodoListUUID: l.ListUuid, OwnerUUID: aUser.UserUUID}
+			tasks, err := t.ReadAllInList(s.DbWorker)
+			if err != nil && err.Error() != "404" {
+				log.Error(service.ExportErr, err)
+				return
+			}
+			if tasks == nil {
+				tasks = []Task{}
+			}
+
+			err = e.list(exportList{readTodoList: l, Tasks: tasks})
+			if err != nil {
+				log.Error(service.ExportErr, err)
+				return
+			}
+		}
+
+		err = e.end(profile, sessions)
+		if err != nil {
+			log.Error(service.ExportErr, err)
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"id":     aUser.UserUUID,
+			"format": format,
+			"lists":  len(lists),
+		}).Info(service.ExportSuccess)
+	}
+}
@@ -0,0 +1,233 @@
+package todoList
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todoApp/api/user"
+)
+
+const (
+	exportJSON     = "json"
+	exportCSV      = "csv"
+	exportMarkdown = "md"
+
+	exportVersion = 1
+)
+
+var csvHeader = []string{
+	"list_id", "list_title", "task_id", "title", "description", "status",
+	"priority", "order", "start_date", "deadline", "added_date", "completed_at",
+}
+
+type exportList struct {
+	readTodoList
+	Tasks []Task `json:"tasks"`
+}
+
+type exportProfile struct {
+	UserUUID uuid.UUID `json:"id"`
+	Email    string    `json:"email"`
+	Username string    `json:"login"`
+	Name     string    `json:"name"`
+	Surname  string    `json:"surname"`
+}
+
+// exporter writes lists one by one so the whole account never has to be
+// held in memory.
+type exporter interface {
+	begin() error
+	list(l exportList) error
+	end(profile *exportProfile, sessions []user.Session) error
+}
+
+func (t *Task) ReadAllInList(dbw dbWorker) ([]Task, error) {
+	var tasks []Task
+	params := map[string]any{
+		"todo_list_uuid": t.TodoListUUID,
+		"owner_uuid":     t.OwnerUUID,
+		"order":          "asc",
+		"sort_by":        "created_at",
+	}
+	err := dbw.ReadManyRecords(Task{}, &tasks, params)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func newExporter(format string, w io.Writer) exporter {
+	switch format {
+	case exportCSV:
+		return &csvExporter{w: csv.NewWriter(w)}
+	case exportMarkdown:
+		return &mdExporter{w: w}
+	default:
+		return &jsonExporter{w: w}
+	}
+}
+
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExporter) begin() error {
+	_, err := fmt.Fprintf(e.w, `{"version":%d,"exportedAt":"%s","lists":[`, exportVersion, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func (e *jsonExporter) list(l exportList) error {
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	bytes, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(bytes)
+	return err
+}
+
+func (e *jsonExporter) end(profile *exportProfile, sessions []user.Session) error {
+	if _, err := io.WriteString(e.w, "]"); err != nil {
+		return err
+	}
+
+	if profile != nil {
+		bytes, err := json.Marshal(profile)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(e.w, `,"profile":%s`, bytes); err != nil {
+			return err
+		}
+	}
+
+	if sessions != nil {
+		bytes, err := json.Marshal(sessions)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(e.w, `,"sessions":%s`, bytes); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(e.w, "}")
+	return err
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvExporter) list(l exportList) error {
+	if len(l.Tasks) == 0 {
+		row := make([]string, len(csvHeader))
+		row[0], row[1] = l.ListUuid.String(), l.Title
+		return e.w.Write(row)
+	}
+
+	for _, t := range l.Tasks {
+		err := e.w.Write([]string{
+			l.ListUuid.String(),
+			l.Title,
+			t.TaskUUID.String(),
+			t.Title,
+			t.Description,
+			strconv.Itoa(t.Status),
+			strconv.Itoa(t.Priority),
+			strconv.Itoa(t.Order),
+			formatExportTime(t.StartDate),
+			formatExportTime(t.Deadline),
+			formatExportTime(&t.AddedDate),
+			formatExportTime(t.CompletedAt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// end ignores profile and sessions, they don't fit the one row per task
+// layout.
+func (e *csvExporter) end(*exportProfile, []user.Session) error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type mdExporter struct {
+	w io.Writer
+}
+
+func (e *mdExporter) begin() error {
+	_, err := fmt.Fprintf(e.w, "# Todo lists\n\nExported %s\n", time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func (e *mdExporter) list(l exportList) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", l.Title)
+
+	for _, t := range l.Tasks {
+		box := " "
+		if t.Status == TaskStatusCompleted {
+			box = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s", box, t.Title)
+		if t.Deadline != nil {
+			fmt.Fprintf(&b, " (due %s)", formatExportTime(t.Deadline))
+		}
+		b.WriteString("\n")
+
+		if t.Description != "" {
+			for _, line := range strings.Split(t.Description, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+	}
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *mdExporter) end(profile *exportProfile, sessions []user.Session) error {
+	var b strings.Builder
+
+	if profile != nil {
+		fmt.Fprintf(&b, "\n## Profile\n\n- Email: %s\n- Login: %s\n- Name: %s %s\n",
+			profile.Email, profile.Username, profile.Name, profile.Surname)
+	}
+
+	if sessions != nil {
+		b.WriteString("\n## Sessions\n\n")
+		for _, s := range sessions {
+			fmt.Fprintf(&b, "- %s, expires %s\n", s.ClientInfo, s.Expires.UTC().Format(time.RFC3339))
+		}
+	}
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
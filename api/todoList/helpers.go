@@ -1,26 +1,65 @@
 package todoList
 
 import (
+	"errors"
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"strings"
 	"todoApp/api/service"
+	"todoApp/types"
 )
 
+// isAuth answers the request itself when authentication or the token scope
+// check fails, callers only have to return.
 func (a *authUser) isAuth(w http.ResponseWriter, r *http.Request, s *Service) error {
-	token, err := r.Cookie(service.SessionTokenName)
+	token, err := service.ReadToken(r)
 	if err != nil {
-		service.UnauthorizedResponse(w, "")
+		w.WriteHeader(http.StatusUnauthorized)
 		log.Error(service.TokenReadErr, err.Error())
+		service.UnauthorizedResponse(w, "")
 		return err
 	}
 
-	authUsr, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token.Value)
+	authUsr, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
 	if err != nil {
-		service.UnauthorizedResponse(w, "")
+		w.WriteHeader(http.StatusUnauthorized)
 		log.Error(service.AuthErr, err)
+		service.UnauthorizedResponse(w, "")
 		return err
 	}
 
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if !authUsr.Allows(resourceOf(r), write) {
+		w.WriteHeader(http.StatusForbidden)
+		log.Error(service.TokenScopeErr)
+		service.ForbiddenResponse(w, service.TokenScopeErr)
+		return errors.New(service.TokenScopeErr)
+	}
+
 	a.AuthUser = authUsr
 	return nil
 }
+
+// resourceOf tells which scope guards the request. Task endpoints and the
+// views built from tasks need tasks scopes, list endpoints lists scopes.
+// Everything else, export and import included, gets no resource, so only
+// credentials without resource scopes pass.
+func resourceOf(r *http.Request) string {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+
+	switch parts[0] {
+	case "tasks", "views", "stats":
+		return types.ResourceTasks
+	case "todo-lists":
+		if len(parts) < 3 {
+			return types.ResourceLists
+		}
+		switch parts[2] {
+		case "tasks", "board", "stats":
+			return types.ResourceTasks
+		case "archive", "unarchive", "activity", "history":
+			return types.ResourceLists
+		}
+	}
+	return ""
+}
@@ -0,0 +1,367 @@
+package todoList
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// getTaskHistoryFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get task history
+//	@Description	Requests all saved versions of the task, newest first
+//	@Tags			History
+//	@Produce		json
+//	@Param			taskId	path		string					true	"task uuid"
+//	@Success		200		{array}		TaskVersion				"OK"
+//	@Success		204		{object}	service.DefaultResponse	"No Content"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/history [get]
+func getTaskHistoryFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		taskId, err := uuid.Parse(r.PathValue("taskId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := TaskVersion{TaskUUID: taskId, OwnerUUID: aUser.UserUUID}
+		versions, err := v.ReadAll(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryReadErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id": taskId,
+		}).Info(service.HistoryReadSuccess)
+		service.OkResponse(w, versions)
+	}
+}
+
+// restoreTaskVersionFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Restore task version
+//	@Description	Reverts the task to the given version. Restoring is saved as a new version.
+//	@Tags			History
+//	@Produce		json
+//	@Param			taskId	path		string					true	"task uuid"
+//	@Param			version	path		int						true	"version number"
+//	@Success		200		{object}	createTask				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/history/{version}/restore [post]
+func restoreTaskVersionFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		taskId, err := uuid.Parse(r.PathValue("taskId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		version, err := strconv.Atoi(r.PathValue("version"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := TaskVersion{TaskUUID: taskId, OwnerUUID: aUser.UserUUID, Version: version}
+		err = v.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryReadErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryReadErr, err)
+			return
+		}
+
+		archived, err := listIsArchived(s.DbWorker, v.TodoListUUID, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+		if archived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, v.TodoListUUID)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
+		before := Task{TaskUUID: taskId, TodoListUUID: v.TodoListUUID, OwnerUUID: aUser.UserUUID}
+		err = before.ReadOne(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		task := createTask{
+			Title:        v.Task.Title,
+			Description:  v.Task.Description,
+			Status:       v.Task.Status,
+			Priority:     v.Task.Priority,
+			Order:        v.Task.Order,
+			StartDate:    v.Task.StartDate,
+			Deadline:     v.Task.Deadline,
+			TodoListUUID: v.TodoListUUID,
+			TaskUUID:     taskId,
+			OwnerUUID:    aUser.UserUUID,
+		}
+
+		err = task.Update(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryRestoreErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryRestoreErr, err)
+			return
+		}
+
+		after := Task{TaskUUID: taskId, TodoListUUID: v.TodoListUUID, OwnerUUID: aUser.UserUUID}
+		err = after.ReadOne(s.DbWorker)
+		if err != nil {
+			log.Error(service.TaskReadErr, err)
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindTask,
+			EntityUUID: taskId,
+			ListUUID:   v.TodoListUUID,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id": taskId,
+			"version": version,
+		}).Info(service.HistoryRestoreSuccess)
+		service.OkResponse(w, task)
+	}
+}
+
+// getListHistoryFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get todo list history
+//	@Description	Requests all saved versions of the list, newest first
+//	@Tags			History
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Success		200		{array}		ListVersion				"OK"
+//	@Success		204		{object}	service.DefaultResponse	"No Content"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/history [get]
+func getListHistoryFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		listId, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := ListVersion{ListUuid: listId, OwnerUuid: aUser.UserUUID}
+		versions, err := v.ReadAll(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryReadErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": listId,
+		}).Info(service.HistoryReadSuccess)
+		service.OkResponse(w, versions)
+	}
+}
+
+// restoreListVersionFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Restore todo list version
+//	@Description	Reverts the list to the given version. Restoring is saved as a new version.
+//	@Tags			History
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Param			version	path		int						true	"version number"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/history/{version}/restore [post]
+func restoreListVersionFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		listId, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		version, err := strconv.Atoi(r.PathValue("version"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := ListVersion{ListUuid: listId, OwnerUuid: aUser.UserUUID, Version: version}
+		err = v.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryReadErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryReadErr, err)
+			return
+		}
+
+		before := TodoList{ListUuid: listId, OwnerUuid: aUser.UserUUID}
+		err = before.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		todoList := createTodoList{
+			ListUuid:  listId,
+			OwnerUuid: aUser.UserUUID,
+			Title:     v.List.Title,
+			Order:     v.List.Order,
+			StartDate: v.List.StartDate,
+			EndDate:   v.List.EndDate,
+			Status:    v.List.Status,
+			TextColor: v.List.TextColor,
+			BgColor:   v.List.BgColor,
+		}
+
+		err = todoList.Update(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.HistoryRestoreErr, err)
+			service.InternalServerErrorResponse(w, service.HistoryRestoreErr, err)
+			return
+		}
+
+		after := TodoList{ListUuid: listId, OwnerUuid: aUser.UserUUID}
+		err = after.Read(s.DbWorker)
+		if err != nil {
+			log.Error(service.ListReadErr, err)
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindList,
+			EntityUUID: listId,
+			ListUUID:   listId,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":      listId,
+			"version": version,
+		}).Info(service.HistoryRestoreSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.HistoryRestoreSuccess,
+			Data:       Item{List: todoList},
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package todoList
+
+import (
+	"github.com/google/uuid"
+	"time"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+type TaskVersion struct {
+	ID           uint      `json:"-" gorm:"primarykey"`
+	TaskUUID     uuid.UUID `json:"-" gorm:"index;uniqueIndex:idx_task_version"`
+	TodoListUUID uuid.UUID `json:"-"`
+	OwnerUUID    uuid.UUID `json:"-" gorm:"index"`
+	Version      int       `json:"version" gorm:"uniqueIndex:idx_task_version" extensions:"x-order=1"`
+	Snapshot     string    `json:"-"`
+	CreatedAt    time.Time `json:"savedAt" extensions:"x-order=2"`
+	Task         Task      `json:"task" gorm:"-" extensions:"x-order=3"`
+}
+
+type ListVersion struct {
+	ID        uint      `json:"-" gorm:"primarykey"`
+	ListUuid  uuid.UUID `json:"-" gorm:"index;uniqueIndex:idx_list_version"`
+	OwnerUuid uuid.UUID `json:"-" gorm:"index"`
+	Version   int       `json:"version" gorm:"uniqueIndex:idx_list_version" extensions:"x-order=1"`
+	Snapshot  string    `json:"-"`
+	CreatedAt time.Time `json:"savedAt" extensions:"x-order=2"`
+	List      TodoList  `json:"list" gorm:"-" extensions:"x-order=3"`
+}
+
+// versionAttempts is how often saving a version is tried. Version numbers
+// are unique, a concurrent save that took the number makes it try the next.
+const versionAttempts = 3
+
+// saveTaskVersion appends the current state of the task to its history.
+// Each attempt runs in its own (nested) transaction, so a failed one
+// doesn't abort the transaction of the caller.
+func saveTaskVersion(dbw dbWorker, t Task) error {
+	snapshot, err := service.SerializeJSON(t)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = dbw.Transaction(func(tx types.DatabaseWorker) error {
+			var last []TaskVersion
+			params := map[string]any{"task_uuid": t.TaskUUID, "order": "desc", "sort_by": "version", "count": 1, "page": 1}
+			err := tx.ReadWithPagination(&last, params)
+			if err != nil && err.Error() != "404" {
+				return err
+			}
+
+			v := TaskVersion{
+				TaskUUID:     t.TaskUUID,
+				TodoListUUID: t.TodoListUUID,
+				OwnerUUID:    t.OwnerUUID,
+				Version:      1,
+				Snapshot:     string(snapshot),
+			}
+			if len(last) > 0 {
+				v.Version = last[0].Version + 1
+			}
+			return tx.CreateRecord(&v)
+		})
+		if err == nil || attempt == versionAttempts {
+			return err
+		}
+	}
+}
+
+func saveListVersion(dbw dbWorker, t TodoList) error {
+	snapshot, err := service.SerializeJSON(t)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = dbw.Transaction(func(tx types.DatabaseWorker) error {
+			var last []ListVersion
+			params := map[string]any{"list_uuid": t.ListUuid, "order": "desc", "sort_by": "version", "count": 1, "page": 1}
+			err := tx.ReadWithPagination(&last, params)
+			if err != nil && err.Error() != "404" {
+				return err
+			}
+
+			v := ListVersion{
+				ListUuid:  t.ListUuid,
+				OwnerUuid: t.OwnerUuid,
+				Version:   1,
+				Snapshot:  string(snapshot),
+			}
+			if len(last) > 0 {
+				v.Version = last[0].Version + 1
+			}
+			return tx.CreateRecord(&v)
+		})
+		if err == nil || attempt == versionAttempts {
+			return err
+		}
+	}
+}
+
+func (v *TaskVersion) ReadAll(dbw dbWorker) ([]TaskVersion, error) {
+	var versions []TaskVersion
+	params := map[string]any{"task_uuid": v.TaskUUID, "owner_uuid": v.OwnerUUID, "order": "desc", "sort_by": "version"}
+	err := dbw.ReadManyRecords(TaskVersion{}, &versions, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		err = service.DeserializeJSON([]byte(versions[i].Snapshot), &versions[i].Task)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+func (v *TaskVersion) Read(dbw dbWorker) error {
+	params := map[string]any{"task_uuid": v.TaskUUID, "owner_uuid": v.OwnerUUID, "version": v.Version}
+	err := dbw.ReadOneRecord(v, params)
+	if err != nil {
+		return err
+	}
+	return service.DeserializeJSON([]byte(v.Snapshot), &v.Task)
+}
+
+func (v *ListVersion) ReadAll(dbw dbWorker) ([]ListVersion, error) {
+	var versions []ListVersion
+	params := map[string]any{"list_uuid": v.ListUuid, "owner_uuid": v.OwnerUuid, "order": "desc", "sort_by": "version"}
+	err := dbw.ReadManyRecords(ListVersion{}, &versions, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		err = service.DeserializeJSON([]byte(versions[i].Snapshot), &versions[i].List)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+func (v *ListVersion) Read(dbw dbWorker) error {
+	params := map[string]any{"list_uuid": v.ListUuid, "owner_uuid": v.OwnerUuid, "version": v.Version}
+	err := dbw.ReadOneRecord(v, params)
+	if err != nil {
+		return err
+	}
+	return service.DeserializeJSON([]byte(v.Snapshot), &v.List)
+}
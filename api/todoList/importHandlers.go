@@ -0,0 +1,180 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+const maxImportSize = 10 << 20
+
+var errImportTooLarge = errors.New("import is larger than 10 MB")
+
+// importFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Import data
+//	@Description	Creates lists and tasks from an upload in one transaction. Send the file as multipart "file" field or as raw body. Formats: json (this app's export), csv, todoist (sync API JSON), trello (board JSON). CSV column names are mapped with a JSON object in "mapping", e.g. {"list":"Project","title":"Task","deadline":"Due"}; defaults match the CSV export. With dryRun=true nothing is created, the report shows what would be.
+//	@Tags			Export
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			format	query		string					true	"json/csv/todoist/trello"
+//	@Param			dryRun	query		bool					false	"Only validate and report"
+//	@Param			mapping	query		string					false	"CSV column mapping"
+//	@Param			file	formData	file					false	"File to import"
+//	@Success		200		{object}	importReport			"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/import [post]
+func importFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		// Multipart bodies get some room for boundaries and other fields,
+		// the file itself is held to maxImportSize below.
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20)
+
+		var data []byte
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			err = r.ParseMultipartForm(maxImportSize)
+			if err == nil {
+				file, _, ferr := r.FormFile("file")
+				if ferr != nil {
+					err = ferr
+				} else {
+					data, err = io.ReadAll(io.LimitReader(file, maxImportSize+1))
+					_ = file.Close()
+				}
+			}
+		} else {
+			data, err = io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+		}
+		if err == nil && len(data) > maxImportSize {
+			err = errImportTooLarge
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		format := strings.ToLower(r.FormValue("format"))
+		dryRun, _ := strconv.ParseBool(r.FormValue("dryRun"))
+
+		lists, err := parseImport(format, data, r.FormValue("mapping"))
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.ImportParseErr, err)
+			service.UnprocessableEntityResponse(w, service.ImportParseErr, err)
+			return
+		}
+
+		report := validateImport(lists)
+		report.DryRun = dryRun
+
+		if len(report.Errors) > 0 && !dryRun {
+			w.WriteHeader(http.StatusBadRequest)
+			log.WithFields(log.Fields{
+				"errors": len(report.Errors),
+			}).Error(service.ValidationErr)
+			service.BadRequestDetailsResponse(w, service.ValidationErr, report)
+			return
+		}
+
+		if dryRun {
+			w.WriteHeader(http.StatusOK)
+			log.WithFields(log.Fields{
+				"lists": report.Lists,
+				"tasks": report.Tasks,
+			}).Info(service.ImportDryRunSuccess)
+			service.OkResponse(w, report)
+			return
+		}
+
+		var createdLists []createTodoList
+		var createdTasks []Task
+		err = s.DbWorker.Transaction(func(tx types.DatabaseWorker) error {
+			for _, l := range lists {
+				list := l.createTodoList
+				list.ListUuid = uuid.New()
+				list.OwnerUuid = aUser.UserUUID
+				err := list.Create(tx)
+				if err != nil {
+					return err
+				}
+				createdLists = append(createdLists, list)
+
+				for _, t := range l.Tasks {
+					task := Task{
+						Description:  t.Description,
+						Title:        t.Title,
+						Status:       t.Status,
+						Priority:     t.Priority,
+						StartDate:    t.StartDate,
+						Deadline:     t.Deadline,
+						TaskUUID:     uuid.New(),
+						TodoListUUID: list.ListUuid,
+						Order:        t.Order,
+						OwnerUUID:    aUser.UserUUID,
+					}
+					err = task.Create(tx)
+					if err != nil {
+						return err
+					}
+					createdTasks = append(createdTasks, task)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ImportErr, err)
+			service.InternalServerErrorResponse(w, service.ImportErr, err)
+			return
+		}
+
+		for _, l := range createdLists {
+			audit.Record(s.DbWorker, r, audit.Event{
+				ActorUUID:  aUser.UserUUID,
+				EntityKind: audit.KindList,
+				EntityUUID: l.ListUuid,
+				ListUUID:   l.ListUuid,
+				Action:     audit.ActionCreate,
+			}, nil, l)
+		}
+		for _, t := range createdTasks {
+			audit.Record(s.DbWorker, r, audit.Event{
+				ActorUUID:  aUser.UserUUID,
+				EntityKind: audit.KindTask,
+				EntityUUID: t.TaskUUID,
+				ListUUID:   t.TodoListUUID,
+				Action:     audit.ActionCreate,
+			}, nil, t)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":     aUser.UserUUID,
+			"format": format,
+			"lists":  report.Lists,
+			"tasks":  report.Tasks,
+		}).Info(service.ImportSuccess)
+		service.OkResponse(w, report)
+	}
+}
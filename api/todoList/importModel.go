@@ -0,0 +1,369 @@
+package todoList
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todoApp/api/service"
+)
+
+const (
+	importJSON    = "json"
+	importCSV     = "csv"
+	importTodoist = "todoist"
+	importTrello  = "trello"
+)
+
+// importList is a format independent list parsed from an upload.
+type importList struct {
+	createTodoList
+	Tasks []createTask `json:"tasks"`
+}
+
+type importReport struct {
+	DryRun bool              `json:"dryRun" extensions:"x-order=1"`
+	Lists  int               `json:"lists" extensions:"x-order=2"`
+	Tasks  int               `json:"tasks" extensions:"x-order=3"`
+	Items  []importItemCount `json:"items" extensions:"x-order=4"`
+	Errors []string          `json:"errors" extensions:"x-order=5"`
+}
+
+type importItemCount struct {
+	Title string `json:"title"`
+	Tasks int    `json:"tasks"`
+}
+
+// csvMapping holds header names of the uploaded CSV for every task field.
+// Defaults match the CSV export.
+type csvMapping struct {
+	List        string `json:"list"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Priority    string `json:"priority"`
+	Order       string `json:"order"`
+	StartDate   string `json:"startDate"`
+	Deadline    string `json:"deadline"`
+}
+
+var defaultCSVMapping = csvMapping{
+	List:        "list_title",
+	Title:       "title",
+	Description: "description",
+	Status:      "status",
+	Priority:    "priority",
+	Order:       "order",
+	StartDate:   "start_date",
+	Deadline:    "deadline",
+}
+
+var importTimeFormats = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "02-01-2006 15:04:05", "02-01-2006"}
+
+func parseImport(format string, data []byte, mapping string) ([]importList, error) {
+	switch format {
+	case importJSON:
+		return parseAppJSON(data)
+	case importCSV:
+		return parseCSV(data, mapping)
+	case importTodoist:
+		return parseTodoist(data)
+	case importTrello:
+		return parseTrello(data)
+	default:
+		return nil, errors.New("format has to be one of: json, csv, todoist, trello")
+	}
+}
+
+func parseAppJSON(data []byte) ([]importList, error) {
+	var export struct {
+		Lists []importList `json:"lists"`
+	}
+	err := service.DeserializeJSON(data, &export)
+	if err != nil {
+		return nil, err
+	}
+	return export.Lists, nil
+}
+
+func parseCSV(data []byte, mappingJSON string) ([]importList, error) {
+	mapping := defaultCSVMapping
+	if mappingJSON != "" {
+		err := service.DeserializeJSON([]byte(mappingJSON), &mapping)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns[mapping.Title]; !ok {
+		return nil, fmt.Errorf("title column %q not found", mapping.Title)
+	}
+
+	var lists []importList
+	index := make(map[string]int)
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		listTitle := get(mapping.List)
+		if listTitle == "" {
+			listTitle = "Imported"
+		}
+		i, ok := index[listTitle]
+		if !ok {
+			i = len(lists)
+			index[listTitle] = i
+			lists = append(lists, importList{createTodoList: createTodoList{Title: listTitle, Order: i}})
+		}
+
+		if get(mapping.Title) == "" && get(mapping.Description) == "" {
+			continue
+		}
+
+		task := createTask{
+			Title:       get(mapping.Title),
+			Description: get(mapping.Description),
+			Status:      parseImportStatus(get(mapping.Status)),
+		}
+		task.Priority, _ = strconv.Atoi(get(mapping.Priority))
+		task.Order, _ = strconv.Atoi(get(mapping.Order))
+
+		task.StartDate, err = parseImportTime(get(mapping.StartDate))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		task.Deadline, err = parseImportTime(get(mapping.Deadline))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		lists[i].Tasks = append(lists[i].Tasks, task)
+	}
+	return lists, nil
+}
+
+// parseTodoist reads the JSON returned by Todoist sync API (projects and
+// items). Todoist priority goes from 1 (normal) to 4 (urgent).
+func parseTodoist(data []byte) ([]importList, error) {
+	var export struct {
+		Projects []struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			ChildOrder int    `json:"child_order"`
+			IsArchived bool   `json:"is_archived"`
+			IsDeleted  bool   `json:"is_deleted"`
+		} `json:"projects"`
+		Items []struct {
+			ProjectID   string `json:"project_id"`
+			Content     string `json:"content"`
+			Description string `json:"description"`
+			Priority    int    `json:"priority"`
+			ChildOrder  int    `json:"child_order"`
+			Checked     bool   `json:"checked"`
+			IsDeleted   bool   `json:"is_deleted"`
+			Due         *struct {
+				Date string `json:"date"`
+			} `json:"due"`
+		} `json:"items"`
+	}
+	err := service.DeserializeJSON(data, &export)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []importList
+	index := make(map[string]int)
+	for _, p := range export.Projects {
+		if p.IsDeleted {
+			continue
+		}
+		index[p.ID] = len(lists)
+		lists = append(lists, importList{createTodoList: createTodoList{Title: p.Name, Order: p.ChildOrder}})
+	}
+
+	for _, item := range export.Items {
+		i, ok := index[item.ProjectID]
+		if !ok || item.IsDeleted {
+			continue
+		}
+
+		task := createTask{
+			Title:       item.Content,
+			Description: item.Description,
+			Order:       item.ChildOrder,
+			Priority:    todoistPriority(item.Priority),
+			Status:      TaskStatusNew,
+		}
+		if item.Checked {
+			task.Status = TaskStatusCompleted
+		}
+		if item.Due != nil {
+			task.Deadline, err = parseImportTime(item.Due.Date)
+			if err != nil {
+				return nil, err
+			}
+		}
+		lists[i].Tasks = append(lists[i].Tasks, task)
+	}
+	return lists, nil
+}
+
+// parseTrello reads a Trello board export. Every Trello list becomes a todo
+// list, cards become tasks. Closed lists and cards are skipped.
+func parseTrello(data []byte) ([]importList, error) {
+	var board struct {
+		Lists []struct {
+			ID     string  `json:"id"`
+			Name   string  `json:"name"`
+			Closed bool    `json:"closed"`
+			Pos    float64 `json:"pos"`
+		} `json:"lists"`
+		Cards []struct {
+			IDList      string  `json:"idList"`
+			Name        string  `json:"name"`
+			Desc        string  `json:"desc"`
+			Closed      bool    `json:"closed"`
+			Pos         float64 `json:"pos"`
+			Start       string  `json:"start"`
+			Due         string  `json:"due"`
+			DueComplete bool    `json:"dueComplete"`
+		} `json:"cards"`
+	}
+	err := service.DeserializeJSON(data, &board)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []importList
+	index := make(map[string]int)
+	for _, l := range board.Lists {
+		if l.Closed {
+			continue
+		}
+		index[l.ID] = len(lists)
+		lists = append(lists, importList{createTodoList: createTodoList{Title: l.Name, Order: len(lists)}})
+	}
+
+	for _, card := range board.Cards {
+		i, ok := index[card.IDList]
+		if !ok || card.Closed {
+			continue
+		}
+
+		task := createTask{
+			Title:       card.Name,
+			Description: card.Desc,
+			Order:       len(lists[i].Tasks),
+			Status:      TaskStatusNew,
+		}
+		if card.DueComplete {
+			task.Status = TaskStatusCompleted
+		}
+		task.StartDate, err = parseImportTime(card.Start)
+		if err != nil {
+			return nil, err
+		}
+		task.Deadline, err = parseImportTime(card.Due)
+		if err != nil {
+			return nil, err
+		}
+		lists[i].Tasks = append(lists[i].Tasks, task)
+	}
+	return lists, nil
+}
+
+func todoistPriority(p int) int {
+	switch p {
+	case 4:
+		return TaskPriorityUrgently
+	case 3:
+		return TaskPriorityHi
+	case 2:
+		return TaskPriorityMiddle
+	default:
+		return TaskPriorityLow
+	}
+}
+
+func parseImportStatus(value string) int {
+	switch strings.ToLower(value) {
+	case "", "new", "todo", "false":
+		return TaskStatusNew
+	case "x", "done", "completed", "true":
+		return TaskStatusCompleted
+	case "in progress", "inprogress":
+		return TaskStatusInProgress
+	}
+
+	status, err := strconv.Atoi(value)
+	if err != nil {
+		return TaskStatusNew
+	}
+	return status
+}
+
+func parseImportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range importTimeFormats {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("can't parse date %q", value)
+}
+
+// validateImport checks every title and returns a report of what would be
+// created.
+func validateImport(lists []importList) importReport {
+	report := importReport{Errors: []string{}, Items: []importItemCount{}}
+
+	for i, l := range lists {
+		err := l.validateTitle()
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("lists[%d]: %s", i, err))
+		}
+
+		for j, t := range l.Tasks {
+			err = t.validateTitle()
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("lists[%d].tasks[%d]: %s", i, j, err))
+			}
+		}
+
+		report.Lists++
+		report.Tasks += len(l.Tasks)
+		report.Items = append(report.Items, importItemCount{Title: l.Title, Tasks: len(l.Tasks)})
+	}
+	return report
+}
@@ -31,5 +31,35 @@ func Init(s *Service) {
 		log.Fatal(service.TableInitErr, err)
 	}
 
+	err = s.DbWorker.InitTable(&TaskVersion{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&ListVersion{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&SavedView{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&SavedViewShare{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&BoardColumn{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&TimeEntry{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
 	addRoutes(s)
 }
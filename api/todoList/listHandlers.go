@@ -5,6 +5,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strconv"
+	"todoApp/api/audit"
 	"todoApp/api/service"
 )
 
@@ -30,9 +32,6 @@ func createListFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -72,6 +71,14 @@ func createListFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindList,
+			EntityUUID: todoList.ListUuid,
+			ListUUID:   todoList.ListUuid,
+			Action:     audit.ActionCreate,
+		}, nil, todoList)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id":    todoList.ListUuid,
@@ -96,11 +103,12 @@ func createListFunc(s *Service) http.HandlerFunc {
 //	@Description	Requests all todo list
 //	@Tags			Todo lists
 //	@Produce		json
-//	@Param			order	query		string					false	"asc/desc (default)"
-//	@Success		200		{array}		readTodoList			"OK"
-//	@Success		204		{array}		service.DefaultResponse	"No Content"
-//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
-//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Param			order			query		string					false	"asc/desc (default)"
+//	@Param			includeArchived	query		bool					false	"Show archived lists too"
+//	@Success		200				{array}		readTodoList			"OK"
+//	@Success		204				{array}		service.DefaultResponse	"No Content"
+//	@Failure		401				{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500				{object}	service.errorResponse	"Internal server error"
 //	@Router			/todo-lists [get]
 func getAllListsFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -109,16 +117,14 @@ func getAllListsFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
 		order := validateOrder(r.URL.Query().Get("order"))
+		includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("includeArchived"))
 
 		todoLists := readTodoList{}
-		lists, err := todoLists.GetAllLists(s.DbWorker, aUser, order)
+		lists, err := todoLists.GetAllLists(s.DbWorker, aUser, order, includeArchived)
 		if err != nil {
 			if err.Error() == "404" {
 				w.WriteHeader(http.StatusNoContent)
@@ -160,9 +166,6 @@ func updateListFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -191,6 +194,21 @@ func updateListFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		before := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = before.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
 		err = todoList.Update(s.DbWorker)
 		if err != nil {
 			if err.Error() == "404" {
@@ -205,6 +223,20 @@ func updateListFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		after := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = after.Read(s.DbWorker)
+		if err != nil {
+			log.Error(service.ListReadErr, err)
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindList,
+			EntityUUID: id,
+			ListUUID:   id,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id": todoList.ListUuid,
@@ -240,9 +272,6 @@ func deleteListFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -255,7 +284,10 @@ func deleteListFunc(s *Service) http.HandlerFunc {
 		}
 
 		todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
-		err = todoList.Delete(s.DbWorker)
+		err = todoList.Read(s.DbWorker)
+		if err == nil {
+			err = todoList.Delete(s.DbWorker)
+		}
 
 		if err != nil {
 			if err.Error() == "404" {
@@ -270,6 +302,14 @@ func deleteListFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindList,
+			EntityUUID: id,
+			ListUUID:   id,
+			Action:     audit.ActionDelete,
+		}, todoList, nil)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id": todoList.ListUuid,
@@ -283,3 +323,197 @@ func deleteListFunc(s *Service) http.HandlerFunc {
 		})
 	}
 }
+
+// getListActivityFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get todo list activity
+//	@Description	Requests audit events of the list and its tasks. Dates are RFC 3339. Defaults: order=desc, count=50, page=1
+//	@Tags			Todo lists
+//	@Produce		json
+//	@Param			listId		path		string					true	"List UUID"
+//	@Param			entityKind	query		string					false	"list/task"
+//	@Param			entityId	query		string					false	"Entity UUID"
+//	@Param			action		query		string					false	"create/update/delete"
+//	@Param			from		query		string					false	"From date"
+//	@Param			to			query		string					false	"To date"
+//	@Param			order		query		string					false	"asc/desc (default)"
+//	@Param			count		query		string					false	"Count (number of events to show per page)"
+//	@Param			page		query		string					false	"Page number"
+//	@Success		200			{array}		audit.Event				"OK"
+//	@Success		204			{object}	service.DefaultResponse	"No Content"
+//	@Failure		400			{object}	service.errorResponse	"Bad request"
+//	@Failure		401			{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404			{object}	service.errorResponse	"Not Found"
+//	@Failure		500			{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/activity [get]
+func getListActivityFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		params, err := audit.FilterParams(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+		params["list_uuid"] = id
+
+		todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = todoList.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		var e audit.Event
+		events, err := e.ReadMany(s.DbWorker, params)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.AuditReadErr, err)
+			service.InternalServerErrorResponse(w, service.AuditReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.AuditReadSuccess)
+		service.OkResponse(w, events)
+	}
+}
+
+// archiveListFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Archive todo list
+//	@Description	Archives todo list. Archived lists are hidden from the list of lists and their tasks are read-only.
+//	@Tags			Todo lists
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/archive [post]
+func archiveListFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changeListStatus(w, r, s, ListStatusArchived)
+	}
+}
+
+// unarchiveListFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Unarchive todo list
+//	@Description	Returns archived todo list back to active lists
+//	@Tags			Todo lists
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/unarchive [post]
+func unarchiveListFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changeListStatus(w, r, s, ListStatusActive)
+	}
+}
+
+func changeListStatus(w http.ResponseWriter, r *http.Request, s *Service, status ListStatus) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var aUser authUser
+	err := aUser.isAuth(w, r, s)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("listId"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error(service.ParseErr, err)
+		service.BadRequestResponse(w, service.ParseErr, err)
+		return
+	}
+
+	todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+	err = todoList.Read(s.DbWorker)
+	if err != nil {
+		if err.Error() == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.DBNotFound)
+			service.NotFoundResponse(w, "")
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.ListReadErr, err)
+		service.InternalServerErrorResponse(w, service.ListReadErr, err)
+		return
+	}
+	before := todoList
+
+	err = todoList.SetStatus(s.DbWorker, status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.ListUpdateErr, err)
+		service.InternalServerErrorResponse(w, service.ListUpdateErr, err)
+		return
+	}
+
+	audit.Record(s.DbWorker, r, audit.Event{
+		ActorUUID:  aUser.UserUUID,
+		EntityKind: audit.KindList,
+		EntityUUID: id,
+		ListUUID:   id,
+		Action:     audit.ActionUpdate,
+	}, before, todoList)
+
+	msg := service.TodoListArchiveSuccess
+	if status == ListStatusActive {
+		msg = service.TodoListUnarchiveSuccess
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.WithFields(log.Fields{
+		"id": id,
+	}).Info(msg)
+
+	service.OkResponse(w, service.DefaultResponse{
+		ResultCode: 0,
+		HttpCode:   http.StatusOK,
+		Messages:   msg,
+		Data:       "",
+	})
+}
@@ -4,6 +4,16 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
+	"todoApp/types"
+)
+
+// ListStatus tells whether a list is in use. Archived lists are hidden from
+// the list of lists and their tasks can't be changed.
+type ListStatus int
+
+const (
+	ListStatusActive ListStatus = iota
+	ListStatusArchived
 )
 
 type TodoList struct {
@@ -14,7 +24,7 @@ type TodoList struct {
 	OwnerUuid  uuid.UUID  `json:"-" gorm:"index"`
 	StartDate  *time.Time `json:"startDate"`
 	EndDate    *time.Time `json:"endDate"`
-	Status     int        `json:"status"`
+	Status     ListStatus `json:"status"`
 	TextColor  string     `json:"textColor"`
 	BgColor    string     `json:"backgroundColor"`
 }
@@ -26,22 +36,27 @@ type createTodoList struct {
 	Order     int        `json:"order" extensions:"x-order=2"`
 	StartDate *time.Time `json:"startDate"`
 	EndDate   *time.Time `json:"endDate"`
-	Status    int        `json:"status"`
+	Status    ListStatus `json:"status"`
 	TextColor string     `json:"textColor"`
 	BgColor   string     `json:"backgroundColor"`
 }
 
 type readTodoList struct {
-	ListUuid  uuid.UUID  `json:"id"`
-	Title     string     `json:"title"`
-	AddedDate time.Time  `json:"addedDate" gorm:"column:created_at"`
-	Order     int        `json:"order"`
-	OwnerUuid uuid.UUID  `json:"-"`
-	StartDate *time.Time `json:"startDate"`
-	EndDate   *time.Time `json:"endDate"`
-	Status    int        `json:"status"`
-	TextColor string     `json:"textColor"`
-	BgColor   string     `json:"backgroundColor"`
+	ListUuid    uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	AddedDate   time.Time  `json:"addedDate" gorm:"column:created_at"`
+	Order       int        `json:"order"`
+	OwnerUuid   uuid.UUID  `json:"-"`
+	StartDate   *time.Time `json:"startDate"`
+	EndDate     *time.Time `json:"endDate"`
+	Status      ListStatus `json:"status"`
+	TextColor   string     `json:"textColor"`
+	BgColor     string     `json:"backgroundColor"`
+	TrackedTime int64      `json:"trackedTime" gorm:"-"`
+}
+
+type listStatusUpdate struct {
+	Status ListStatus
 }
 
 type Item struct {
@@ -60,31 +75,99 @@ func (c *createTodoList) Create(dbw dbWorker) error {
 		BgColor:   c.BgColor,
 	}
 
-	err := dbw.CreateRecord(&list)
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.CreateRecord(&list)
+		if err != nil {
+			return err
+		}
+		return saveListVersion(tx, list)
+	})
+}
+
+func (t *TodoList) Read(dbw dbWorker) error {
+	params := map[string]any{"list_uuid": t.ListUuid, "owner_uuid": t.OwnerUuid}
+	err := dbw.ReadOneRecord(t, params)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func (r *readTodoList) GetAllLists(dbw dbWorker, aw authUser, order string) ([]readTodoList, error) {
+func (r *readTodoList) GetAllLists(dbw dbWorker, aw authUser, order string, includeArchived bool) ([]readTodoList, error) {
 	var allLists []readTodoList
 	params := map[string]any{"owner_uuid": aw.UserUUID, "order": order, "sort_by": "created_at"}
+	if !includeArchived {
+		params["status"] = ListStatusActive
+	}
 	err := dbw.ReadManyRecords(TodoList{}, &allLists, params)
 	if err != nil {
 		return nil, err
 	}
+
+	ids := make([]uuid.UUID, len(allLists))
+	for i := range allLists {
+		ids[i] = allLists[i].ListUuid
+	}
+	totals, err := sumTrackedTime(dbw, "todo_list_uuid", ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range allLists {
+		allLists[i].TrackedTime = totals[allLists[i].ListUuid]
+	}
 	return allLists, nil
 }
 
+// Update changes list data. Status is kept as is, lists are archived through
+// SetStatus only.
 func (c *createTodoList) Update(dbw dbWorker) error {
-	params := map[string]any{"list_uuid": c.ListUuid, "owner_uuid": c.OwnerUuid}
-	err := dbw.UpdateRecordSubmodel(TodoList{}, c, params)
+	t := TodoList{ListUuid: c.ListUuid, OwnerUuid: c.OwnerUuid}
+	err := t.Read(dbw)
 	if err != nil {
 		return err
 	}
-	return nil
+	c.Status = t.Status
+
+	params := map[string]any{"list_uuid": c.ListUuid, "owner_uuid": c.OwnerUuid}
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.UpdateRecordSubmodel(TodoList{}, c, params)
+		if err != nil {
+			return err
+		}
+
+		t := TodoList{ListUuid: c.ListUuid, OwnerUuid: c.OwnerUuid}
+		err = t.Read(tx)
+		if err != nil {
+			return err
+		}
+		return saveListVersion(tx, t)
+	})
+}
+
+func (t *TodoList) SetStatus(dbw dbWorker, status ListStatus) error {
+	params := map[string]any{"list_uuid": t.ListUuid, "owner_uuid": t.OwnerUuid}
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.UpdateRecordSubmodel(TodoList{}, &listStatusUpdate{Status: status}, params)
+		if err != nil {
+			return err
+		}
+		t.Status = status
+		return saveListVersion(tx, *t)
+	})
+}
+
+// listIsArchived reports whether tasks of the list are read-only. A missing
+// list is not archived, the following query will answer with 404 anyway.
+func listIsArchived(dbw dbWorker, listId, owner uuid.UUID) (bool, error) {
+	t := TodoList{ListUuid: listId, OwnerUuid: owner}
+	err := t.Read(dbw)
+	if err != nil {
+		if err.Error() == "404" {
+			return false, nil
+		}
+		return false, err
+	}
+	return t.Status == ListStatusArchived, nil
 }
 
 func (t *TodoList) Delete(dbw dbWorker) error {
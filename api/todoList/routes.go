@@ -14,15 +14,102 @@ func addRoutes(s *Service) {
 	deleteListHandler := deleteListFunc(s)
 	s.Router.HandleFunc("DELETE /api/v1/todo-lists/{listId}", deleteListHandler)
 
+	archiveListHandler := archiveListFunc(s)
+	s.Router.HandleFunc("POST /api/v1/todo-lists/{listId}/archive", archiveListHandler)
+
+	unarchiveListHandler := unarchiveListFunc(s)
+	s.Router.HandleFunc("POST /api/v1/todo-lists/{listId}/unarchive", unarchiveListHandler)
+
+	getListStatsHandler := getListStatsFunc(s)
+	s.Router.HandleFunc("GET /api/v1/todo-lists/{listId}/stats", getListStatsHandler)
+
+	getStatsHandler := getStatsFunc(s)
+	s.Router.HandleFunc("GET /api/v1/stats", getStatsHandler)
+
+	getTodayViewHandler := getTodayViewFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views/today", getTodayViewHandler)
+
+	getUpcomingViewHandler := getUpcomingViewFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views/upcoming", getUpcomingViewHandler)
+
+	getOverdueViewHandler := getOverdueViewFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views/overdue", getOverdueViewHandler)
+
+	createViewHandler := createViewFunc(s)
+	s.Router.HandleFunc("POST /api/v1/views", createViewHandler)
+
+	getViewsHandler := getViewsFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views", getViewsHandler)
+
+	getViewHandler := getViewFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views/{viewId}", getViewHandler)
+
+	updateViewHandler := updateViewFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/views/{viewId}", updateViewHandler)
+
+	deleteViewHandler := deleteViewFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/views/{viewId}", deleteViewHandler)
+
+	getViewTasksHandler := getViewTasksFunc(s)
+	s.Router.HandleFunc("GET /api/v1/views/{viewId}/tasks", getViewTasksHandler)
+
+	getBoardHandler := getBoardFunc(s)
+	s.Router.HandleFunc("GET /api/v1/todo-lists/{listId}/board", getBoardHandler)
+
+	updateBoardColumnsHandler := updateBoardColumnsFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/todo-lists/{listId}/board/columns", updateBoardColumnsHandler)
+
+	transitionTaskHandler := transitionTaskFunc(s)
+	s.Router.HandleFunc("POST /api/v1/tasks/{taskId}/transition", transitionTaskHandler)
+
+	getTaskTimeHandler := getTaskTimeFunc(s)
+	s.Router.HandleFunc("GET /api/v1/tasks/{taskId}/time", getTaskTimeHandler)
+
+	createTimeEntryHandler := createTimeEntryFunc(s)
+	s.Router.HandleFunc("POST /api/v1/tasks/{taskId}/time", createTimeEntryHandler)
+
+	startTimerHandler := startTimerFunc(s)
+	s.Router.HandleFunc("POST /api/v1/tasks/{taskId}/time/start", startTimerHandler)
+
+	stopTimerHandler := stopTimerFunc(s)
+	s.Router.HandleFunc("POST /api/v1/tasks/{taskId}/time/stop", stopTimerHandler)
+
+	deleteTimeEntryHandler := deleteTimeEntryFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/tasks/{taskId}/time/{entryId}", deleteTimeEntryHandler)
+
+	getListActivityHandler := getListActivityFunc(s)
+	s.Router.HandleFunc("GET /api/v1/todo-lists/{listId}/activity", getListActivityHandler)
+
+	getListHistoryHandler := getListHistoryFunc(s)
+	s.Router.HandleFunc("GET /api/v1/todo-lists/{listId}/history", getListHistoryHandler)
+
+	restoreListVersionHandler := restoreListVersionFunc(s)
+	s.Router.HandleFunc("POST /api/v1/todo-lists/{listId}/history/{version}/restore", restoreListVersionHandler)
+
 	createTaskHandler := createTaskFunc(s)
 	s.Router.HandleFunc("POST /api/v1/todo-lists/{listId}/tasks", createTaskHandler)
 
 	getTaskHandler := getTaskFunc(s)
 	s.Router.HandleFunc("GET /api/v1/todo-lists/{listId}/tasks", getTaskHandler)
 
+	batchTasksHandler := batchTasksFunc(s)
+	s.Router.HandleFunc("POST /api/v1/todo-lists/{listId}/tasks/batch", batchTasksHandler)
+
 	updateTaskHandler := updateTaskFunc(s)
 	s.Router.HandleFunc("PUT /api/v1/todo-lists/{listId}/tasks/{taskId}", updateTaskHandler)
 
 	deleteTaskHandler := deleteTaskFunc(s)
 	s.Router.HandleFunc("DELETE /api/v1/todo-lists/{listId}/tasks/{taskId}", deleteTaskHandler)
+
+	getTaskHistoryHandler := getTaskHistoryFunc(s)
+	s.Router.HandleFunc("GET /api/v1/tasks/{taskId}/history", getTaskHistoryHandler)
+
+	restoreTaskVersionHandler := restoreTaskVersionFunc(s)
+	s.Router.HandleFunc("POST /api/v1/tasks/{taskId}/history/{version}/restore", restoreTaskVersionHandler)
+
+	exportHandler := exportFunc(s)
+	s.Router.HandleFunc("GET /api/v1/export", exportHandler)
+
+	importHandler := importFunc(s)
+	s.Router.HandleFunc("POST /api/v1/import", importHandler)
 }
@@ -0,0 +1,420 @@
+package todoList
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/service"
+	"todoApp/api/user"
+)
+
+// createViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Create saved view
+//	@Description	Saves a named task filter. sharedWith lists the UUIDs of users who may see and run the view, each of them gets their own tasks. They see neither the owner nor the lists of the filter. Tasks have no tags yet, so filtering by tags is not supported.
+//	@Tags			Views
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		createSavedView			true	"View"
+//	@Success		200		{object}	SavedView				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views [post]
+func createViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createSavedView{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONReadErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		v, err := c.Create(s.DbWorker, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewCreateErr, err)
+			service.InternalServerErrorResponse(w, service.ViewCreateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":   v.ViewUUID,
+			"name": v.Name,
+		}).Info(service.ViewCreateSuccess)
+		service.OkResponse(w, v)
+	}
+}
+
+// getViewsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get saved views
+//	@Description	Requests user's own saved views followed by views other users shared with them
+//	@Tags			Views
+//	@Produce		json
+//	@Success		200	{array}		SavedView				"OK"
+//	@Success		204	{object}	service.DefaultResponse	"No Content"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/views [get]
+func getViewsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		var v SavedView
+		views, err := v.ReadAll(s.DbWorker, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewReadErr, err)
+			service.InternalServerErrorResponse(w, service.ViewReadErr, err)
+			return
+		}
+		if len(views) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			log.Info(service.NoContent)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.ViewReadSuccess)
+		service.OkResponse(w, views)
+	}
+}
+
+// getViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get saved view
+//	@Description	Requests saved view owned by user or shared with them
+//	@Tags			Views
+//	@Produce		json
+//	@Param			viewId	path		string					true	"View UUID"
+//	@Success		200		{object}	SavedView				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/{viewId} [get]
+func getViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("viewId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := SavedView{ViewUUID: id}
+		err = v.Read(s.DbWorker, aUser.UserUUID)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewReadErr, err)
+			service.InternalServerErrorResponse(w, service.ViewReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.ViewReadSuccess)
+		service.OkResponse(w, v)
+	}
+}
+
+// updateViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Update saved view
+//	@Description	Replaces name, sharedWith and filter of the view. Only the owner can change it.
+//	@Tags			Views
+//	@Accept			json
+//	@Produce		json
+//	@Param			viewId	path		string					true	"View UUID"
+//	@Param			data	body		createSavedView			true	"View"
+//	@Success		200		{object}	SavedView				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403		{object}	service.errorResponse	"Forbidden"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/{viewId} [put]
+func updateViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("viewId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createSavedView{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONReadErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		v := SavedView{ViewUUID: id}
+		err = v.Read(s.DbWorker, aUser.UserUUID)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewReadErr, err)
+			service.InternalServerErrorResponse(w, service.ViewReadErr, err)
+			return
+		}
+		if v.OwnerUUID != aUser.UserUUID {
+			w.WriteHeader(http.StatusForbidden)
+			log.Error(service.Forbidden, id)
+			service.ForbiddenResponse(w, service.Forbidden)
+			return
+		}
+
+		err = c.Update(s.DbWorker, &v)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.ViewUpdateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.ViewUpdateSuccess)
+		service.OkResponse(w, v)
+	}
+}
+
+// deleteViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Delete saved view
+//	@Description	Deletes saved view. Only the owner can delete it.
+//	@Tags			Views
+//	@Produce		json
+//	@Param			viewId	path		string					true	"View UUID"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/{viewId} [delete]
+func deleteViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("viewId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := SavedView{ViewUUID: id, OwnerUUID: aUser.UserUUID}
+		err = v.Delete(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewDeleteErr, err)
+			service.InternalServerErrorResponse(w, service.ViewDeleteErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.ViewDeleteSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.ViewDeleteSuccess,
+			Data:       "",
+		})
+	}
+}
+
+// getViewTasksFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get tasks of saved view
+//	@Description	Runs saved view against user's tasks. Tasks are sorted by deadline. Defaults: order=desc, count=10, page=1
+//	@Tags			Views
+//	@Produce		json
+//	@Param			viewId	path		string					true	"View UUID"
+//	@Param			order	query		string					false	"asc/desc (default)"
+//	@Param			count	query		string					false	"Count (number of task to show per page)"
+//	@Param			page	query		string					false	"Page number"
+//	@Success		200		{array}		Task					"OK"
+//	@Success		204		{object}	service.DefaultResponse	"No Content"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/{viewId}/tasks [get]
+func getViewTasksFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		q := r.URL.Query()
+		order := validateOrder(q.Get("order"))
+		count := validateQueryInt(q.Get("count"), 10)
+		page := validateQueryInt(q.Get("page"), 1)
+
+		id, err := uuid.Parse(r.PathValue("viewId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		v := SavedView{ViewUUID: id}
+		err = v.Read(s.DbWorker, aUser.UserUUID)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ViewReadErr, err)
+			service.InternalServerErrorResponse(w, service.ViewReadErr, err)
+			return
+		}
+
+		st := user.UserSettings{UserUUID: aUser.UserUUID}
+		err = st.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		tasks, err := v.Tasks(s.DbWorker, aUser.UserUUID, st.Location(), order, count, page)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNoContent)
+				log.Info(service.NoContent)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":    id,
+			"tasks": len(tasks),
+		}).Info(service.TaskReadSuccess)
+		service.OkResponse(w, tasks)
+	}
+}
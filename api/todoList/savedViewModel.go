@@ -0,0 +1,323 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+const maxViewDays = 366
+
+// ViewFilter describes which tasks a saved view shows. Empty fields don't
+// filter anything. DueWithinDays is a window relative to the start of the
+// current day, so "this week" style views stay up to date; it wins over
+// DeadlineFrom/DeadlineTo.
+type ViewFilter struct {
+	Statuses      []int       `json:"statuses" example:"0,1" extensions:"x-order=1"`
+	Priorities    []int       `json:"priorities" example:"2,3" extensions:"x-order=2"`
+	Lists         []uuid.UUID `json:"lists" extensions:"x-order=3"`
+	DeadlineFrom  *time.Time  `json:"deadlineFrom" extensions:"x-order=4"`
+	DeadlineTo    *time.Time  `json:"deadlineTo" extensions:"x-order=5"`
+	DueWithinDays *int        `json:"dueWithinDays" example:"7" extensions:"x-order=6"`
+}
+
+// SavedView is a named filter. The owner can share it with chosen users, it
+// is always executed against the tasks of the user asking.
+type SavedView struct {
+	gorm.Model `json:"-"`
+	ViewUUID   uuid.UUID   `json:"id" gorm:"index" extensions:"x-order=1"`
+	OwnerUUID  uuid.UUID   `json:"-" gorm:"index"`
+	Name       string      `json:"name" extensions:"x-order=2"`
+	Owned      bool        `json:"owned" gorm:"-" extensions:"x-order=3"`
+	SharedWith []uuid.UUID `json:"sharedWith,omitempty" gorm:"-" extensions:"x-order=4"`
+	Definition string      `json:"-"`
+	Filter     ViewFilter  `json:"filter" gorm:"-" extensions:"x-order=5"`
+}
+
+// SavedViewShare gives one user access to someone else's view.
+type SavedViewShare struct {
+	ID       uint      `gorm:"primarykey"`
+	ViewUUID uuid.UUID `gorm:"uniqueIndex:idx_view_share"`
+	UserUUID uuid.UUID `gorm:"uniqueIndex:idx_view_share;index"`
+}
+
+type createSavedView struct {
+	Name       string      `json:"name" example:"My P1s this week" extensions:"x-order=1"`
+	SharedWith []uuid.UUID `json:"sharedWith" extensions:"x-order=2"`
+	Filter     ViewFilter  `json:"filter" extensions:"x-order=3"`
+}
+
+type updateSavedView struct {
+	Name       string
+	Definition string
+}
+
+const maxViewShares = 50
+
+func (c *createSavedView) validate() error {
+	err := validateTitle(c.Name, "name")
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(c.SharedWith, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	c.SharedWith = slices.Compact(c.SharedWith)
+	if len(c.SharedWith) > maxViewShares {
+		return errors.New("a view can be shared with up to 50 users")
+	}
+
+	for _, st := range c.Filter.Statuses {
+		if st < TaskStatusNew || st > TaskStatusDraft {
+			return errors.New("unknown status in filter")
+		}
+	}
+	for _, p := range c.Filter.Priorities {
+		if p < TaskPriorityLow || p > TaskPriorityLater {
+			return errors.New("unknown priority in filter")
+		}
+	}
+
+	f := c.Filter
+	if f.DueWithinDays != nil && (*f.DueWithinDays < 1 || *f.DueWithinDays > maxViewDays) {
+		return errors.New("dueWithinDays has to be between 1 and 366")
+	}
+	if f.DeadlineFrom != nil && f.DeadlineTo != nil && f.DeadlineTo.Before(*f.DeadlineFrom) {
+		return errors.New("deadlineTo is before deadlineFrom")
+	}
+	return nil
+}
+
+func (c *createSavedView) Create(dbw dbWorker, owner uuid.UUID) (SavedView, error) {
+	definition, err := service.SerializeJSON(c.Filter)
+	if err != nil {
+		return SavedView{}, err
+	}
+
+	v := SavedView{
+		ViewUUID:   uuid.New(),
+		OwnerUUID:  owner,
+		Name:       c.Name,
+		Owned:      true,
+		Definition: string(definition),
+		Filter:     c.Filter,
+	}
+	err = dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.CreateRecord(&v)
+		if err != nil {
+			return err
+		}
+		return v.share(tx, c.SharedWith)
+	})
+	if err != nil {
+		return SavedView{}, err
+	}
+	return v, nil
+}
+
+func (c *createSavedView) Update(dbw dbWorker, v *SavedView) error {
+	definition, err := service.SerializeJSON(c.Filter)
+	if err != nil {
+		return err
+	}
+
+	upd := updateSavedView{Name: c.Name, Definition: string(definition)}
+	params := map[string]any{"view_uuid": v.ViewUUID, "owner_uuid": v.OwnerUUID}
+	err = dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.UpdateRecordSubmodel(SavedView{}, &upd, params)
+		if err != nil {
+			return err
+		}
+		return v.share(tx, c.SharedWith)
+	})
+	if err != nil {
+		return err
+	}
+
+	v.Name, v.Definition, v.Filter = c.Name, upd.Definition, c.Filter
+	return nil
+}
+
+// share replaces the users the view is shared with. The owner is skipped,
+// they see the view anyway.
+func (v *SavedView) share(dbw dbWorker, users []uuid.UUID) error {
+	err := dbw.DeleteRecord(&SavedViewShare{}, map[string]any{"view_uuid": v.ViewUUID})
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+
+	v.SharedWith = nil
+	for _, u := range users {
+		if u == v.OwnerUUID {
+			continue
+		}
+		err = dbw.CreateRecord(&SavedViewShare{ViewUUID: v.ViewUUID, UserUUID: u})
+		if err != nil {
+			return err
+		}
+		v.SharedWith = append(v.SharedWith, u)
+	}
+	return nil
+}
+
+// Read loads the view if the user owns it or it is shared with them.
+func (v *SavedView) Read(dbw dbWorker, caller uuid.UUID) error {
+	err := dbw.ReadOneRecord(v, map[string]any{"view_uuid": v.ViewUUID})
+	if err != nil {
+		return err
+	}
+
+	if v.OwnerUUID != caller {
+		var sh SavedViewShare
+		err = dbw.ReadOneRecord(&sh, map[string]any{"view_uuid": v.ViewUUID, "user_uuid": caller})
+		if err != nil {
+			return err
+		}
+	}
+
+	views := []SavedView{*v}
+	err = prepareViews(dbw, views, caller)
+	if err != nil {
+		return err
+	}
+	*v = views[0]
+	return nil
+}
+
+// ReadAll returns user's own views followed by views shared with them.
+func (v *SavedView) ReadAll(dbw dbWorker, caller uuid.UUID) ([]SavedView, error) {
+	var own, shared []SavedView
+	err := dbw.ReadManyRecords(SavedView{}, &own, map[string]any{"owner_uuid": caller, "order": "asc", "sort_by": "created_at"})
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+
+	var shares []SavedViewShare
+	err = dbw.ReadManyRecords(SavedViewShare{}, &shares, map[string]any{"user_uuid": caller})
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+	if len(shares) > 0 {
+		ids := make([]uuid.UUID, 0, len(shares))
+		for _, sh := range shares {
+			ids = append(ids, sh.ViewUUID)
+		}
+		err = dbw.ReadManyRecords(SavedView{}, &shared, map[string]any{"view_uuid IN": ids, "order": "asc", "sort_by": "created_at"})
+		if err != nil && err.Error() != "404" {
+			return nil, err
+		}
+	}
+
+	views := append(own, shared...)
+	err = prepareViews(dbw, views, caller)
+	if err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+// prepareViews decodes the filters. Owners get the users their views are
+// shared with, everybody else doesn't see the lists of the filter, they
+// belong to the owner and don't match anybody else's tasks anyway.
+func prepareViews(dbw dbWorker, views []SavedView, caller uuid.UUID) error {
+	var ownIds []uuid.UUID
+	for i := range views {
+		err := service.DeserializeJSON([]byte(views[i].Definition), &views[i].Filter)
+		if err != nil {
+			return err
+		}
+		views[i].Owned = views[i].OwnerUUID == caller
+		if views[i].Owned {
+			ownIds = append(ownIds, views[i].ViewUUID)
+		} else {
+			views[i].Filter.Lists = nil
+		}
+	}
+	if len(ownIds) == 0 {
+		return nil
+	}
+
+	var shares []SavedViewShare
+	err := dbw.ReadManyRecords(SavedViewShare{}, &shares, map[string]any{"view_uuid IN": ownIds})
+	if err != nil {
+		if err.Error() == "404" {
+			return nil
+		}
+		return err
+	}
+	for i := range views {
+		for _, sh := range shares {
+			if views[i].Owned && sh.ViewUUID == views[i].ViewUUID {
+				views[i].SharedWith = append(views[i].SharedWith, sh.UserUUID)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *SavedView) Delete(dbw dbWorker) error {
+	params := map[string]any{"view_uuid": v.ViewUUID, "owner_uuid": v.OwnerUUID}
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.DeleteRecord(&SavedView{}, params)
+		if err != nil {
+			return err
+		}
+		err = tx.DeleteRecord(&SavedViewShare{}, map[string]any{"view_uuid": v.ViewUUID})
+		if err != nil && err.Error() != "404" {
+			return err
+		}
+		return nil
+	})
+}
+
+// Tasks runs the view against caller's tasks.
+func (v *SavedView) Tasks(dbw dbWorker, caller uuid.UUID, loc *time.Location, order string, count, page int) ([]Task, error) {
+	var tasks []Task
+	params := map[string]any{
+		"owner_uuid": caller,
+		"order":      order,
+		"sort_by":    "deadline",
+		"count":      count,
+		"page":       page,
+	}
+
+	f := v.Filter
+	if len(f.Statuses) > 0 {
+		params["status IN"] = f.Statuses
+	}
+	if len(f.Priorities) > 0 {
+		params["priority IN"] = f.Priorities
+	}
+	if len(f.Lists) > 0 {
+		params["todo_list_uuid IN"] = f.Lists
+	}
+
+	switch {
+	case f.DueWithinDays != nil:
+		from := dayStart(time.Now(), loc)
+		params["deadline >="] = from
+		params["deadline <"] = from.AddDate(0, 0, *f.DueWithinDays)
+	default:
+		if f.DeadlineFrom != nil {
+			params["deadline >="] = *f.DeadlineFrom
+		}
+		if f.DeadlineTo != nil {
+			params["deadline <="] = *f.DeadlineTo
+		}
+	}
+
+	err := dbw.ReadWithPagination(&tasks, params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addTrackedTime(dbw, tasks)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
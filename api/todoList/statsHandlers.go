@@ -0,0 +1,160 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"todoApp/api/service"
+)
+
+// getStatsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get tasks stats
+//	@Description	Requests stats of all user's tasks: counts by status and priority, overdue count, completion rate over time and average time to complete (seconds). Defaults: period=day, periods=30 for days and 12 for weeks
+//	@Tags			Stats
+//	@Produce		json
+//	@Param			period	query		string					false	"day/week"
+//	@Param			periods	query		string					false	"Number of periods to show"
+//	@Success		200		{object}	Stats					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/stats [get]
+func getStatsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		f, err := parseStatsFilter(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+		f.OwnerUUID = aUser.UserUUID
+
+		stats, err := readStats(s.DbWorker, f)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.StatsReadErr, err)
+			service.InternalServerErrorResponse(w, service.StatsReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"user": aUser.UserUUID,
+		}).Info(service.StatsReadSuccess)
+		service.OkResponse(w, stats)
+	}
+}
+
+// getListStatsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get todo list stats
+//	@Description	Requests stats of the list's tasks: counts by status and priority, overdue count, completion rate over time and average time to complete (seconds). Defaults: period=day, periods=30 for days and 12 for weeks
+//	@Tags			Stats
+//	@Produce		json
+//	@Param			listId	path		string					true	"List UUID"
+//	@Param			period	query		string					false	"day/week"
+//	@Param			periods	query		string					false	"Number of periods to show"
+//	@Success		200		{object}	Stats					"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/todo-lists/{listId}/stats [get]
+func getListStatsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("listId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		f, err := parseStatsFilter(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+		f.OwnerUUID = aUser.UserUUID
+		f.TodoListUUID = id
+
+		todoList := TodoList{ListUuid: id, OwnerUuid: aUser.UserUUID}
+		err = todoList.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+
+		stats, err := readStats(s.DbWorker, f)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.StatsReadErr, err)
+			service.InternalServerErrorResponse(w, service.StatsReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": id,
+		}).Info(service.StatsReadSuccess)
+		service.OkResponse(w, stats)
+	}
+}
+
+func parseStatsFilter(q url.Values) (statsFilter, error) {
+	f := statsFilter{Period: "day", Periods: 30}
+
+	switch q.Get("period") {
+	case "", "day":
+	case "week":
+		f.Period = "week"
+		f.Periods = 12
+	default:
+		return f, errors.New("period must be day or week")
+	}
+
+	if q.Has("periods") {
+		periods, err := strconv.Atoi(q.Get("periods"))
+		if err != nil {
+			return f, err
+		}
+		if periods < 1 || periods > 366 {
+			return f, errors.New("periods must be between 1 and 366")
+		}
+		f.Periods = periods
+	}
+	return f, nil
+}
@@ -0,0 +1,170 @@
+package todoList
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+type Stats struct {
+	Total             int64             `json:"total"`
+	ByStatus          map[int]int64     `json:"byStatus"`
+	ByPriority        map[int]int64     `json:"byPriority"`
+	Overdue           int64             `json:"overdue"`
+	CompletionRate    float64           `json:"completionRate"`
+	AvgTimeToComplete float64           `json:"avgTimeToComplete"` // seconds
+	Period            string            `json:"period"`
+	Completion        []CompletionPoint `json:"completion"`
+}
+
+// CompletionPoint holds number of tasks created and completed during one
+// day or week. Rate is completed to created ratio of that period.
+type CompletionPoint struct {
+	Period    time.Time `json:"period"`
+	Created   int64     `json:"created"`
+	Completed int64     `json:"completed"`
+	Rate      float64   `json:"rate"`
+}
+
+type groupCount struct {
+	Key   int
+	Count int64
+}
+
+type periodCount struct {
+	Period time.Time
+	Count  int64
+}
+
+type statsFilter struct {
+	OwnerUUID    uuid.UUID
+	TodoListUUID uuid.UUID
+	Period       string
+	Periods      int
+}
+
+func (f statsFilter) params(extra map[string]any) map[string]any {
+	params := map[string]any{
+		"owner_uuid": f.OwnerUUID,
+	}
+	if f.TodoListUUID != uuid.Nil {
+		params["todo_list_uuid"] = f.TodoListUUID
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	return params
+}
+
+func (f statsFilter) since() time.Time {
+	if f.Period == "week" {
+		return time.Now().AddDate(0, 0, -7*f.Periods)
+	}
+	return time.Now().AddDate(0, 0, -f.Periods)
+}
+
+func readStats(dbw dbWorker, f statsFilter) (Stats, error) {
+	stats := Stats{
+		ByStatus:   map[int]int64{},
+		ByPriority: map[int]int64{},
+		Period:     f.Period,
+		Completion: []CompletionPoint{},
+	}
+
+	var total struct{ Count int64 }
+	err := dbw.Aggregate(&Task{}, &total, f.params(map[string]any{
+		"select": "count(*) AS count",
+	}))
+	if err != nil {
+		return stats, err
+	}
+	stats.Total = total.Count
+
+	for column, dest := range map[string]map[int]int64{"status": stats.ByStatus, "priority": stats.ByPriority} {
+		var rows []groupCount
+		err = dbw.Aggregate(&Task{}, &rows, f.params(map[string]any{
+			"select": fmt.Sprintf("%s AS key, count(*) AS count", column),
+			"group":  column,
+		}))
+		if err != nil {
+			return stats, err
+		}
+		for _, row := range rows {
+			dest[row.Key] = row.Count
+		}
+	}
+
+	var overdue struct{ Count int64 }
+	err = dbw.Aggregate(&Task{}, &overdue, f.params(map[string]any{
+		"select":     "count(*) AS count",
+		"deadline <": time.Now(),
+		"status <>":  TaskStatusCompleted,
+	}))
+	if err != nil {
+		return stats, err
+	}
+	stats.Overdue = overdue.Count
+
+	var avg struct{ Seconds float64 }
+	err = dbw.Aggregate(&Task{}, &avg, f.params(map[string]any{
+		"select":              "coalesce(avg(extract(epoch from completed_at - created_at)), 0) AS seconds",
+		"completed_at IS NOT": nil,
+	}))
+	if err != nil {
+		return stats, err
+	}
+	stats.AvgTimeToComplete = avg.Seconds
+
+	if stats.Total > 0 {
+		stats.CompletionRate = float64(stats.ByStatus[TaskStatusCompleted]) / float64(stats.Total)
+	}
+
+	stats.Completion, err = readCompletion(dbw, f)
+	if err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func readCompletion(dbw dbWorker, f statsFilter) ([]CompletionPoint, error) {
+	since := f.since()
+	points := map[time.Time]*CompletionPoint{}
+
+	for _, column := range []string{"created_at", "completed_at"} {
+		var rows []periodCount
+		err := dbw.Aggregate(&Task{}, &rows, f.params(map[string]any{
+			"select":       fmt.Sprintf("date_trunc('%s', %s) AS period, count(*) AS count", f.Period, column),
+			"group":        "period",
+			column + " >=": since,
+		}))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			p, ok := points[row.Period]
+			if !ok {
+				p = &CompletionPoint{Period: row.Period}
+				points[row.Period] = p
+			}
+			if column == "created_at" {
+				p.Created = row.Count
+			} else {
+				p.Completed = row.Count
+			}
+		}
+	}
+
+	result := make([]CompletionPoint, 0, len(points))
+	for _, p := range points {
+		if p.Created > 0 {
+			p.Rate = float64(p.Completed) / float64(p.Created)
+		}
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period.Before(result[j].Period)
+	})
+	return result, nil
+}
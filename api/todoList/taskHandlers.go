@@ -5,6 +5,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"todoApp/api/audit"
 	"todoApp/api/service"
 )
 
@@ -31,9 +32,6 @@ func createTaskFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -71,6 +69,20 @@ func createTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		archived, err := listIsArchived(s.DbWorker, id, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+		if archived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, id)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
 		newTask := Task{
 			Description:  task.Description,
 			Title:        task.Title,
@@ -93,6 +105,14 @@ func createTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindTask,
+			EntityUUID: newTask.TaskUUID,
+			ListUUID:   newTask.TodoListUUID,
+			Action:     audit.ActionCreate,
+		}, nil, newTask)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"Title": task.Title,
@@ -126,9 +146,6 @@ func getTaskFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -197,9 +214,6 @@ func updateTaskFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -245,6 +259,35 @@ func updateTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		archived, err := listIsArchived(s.DbWorker, listId, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+		if archived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, listId)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
+		before := Task{TaskUUID: taskId, TodoListUUID: listId, OwnerUUID: aUser.UserUUID}
+		err = before.ReadOne(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
 		err = task.Update(s.DbWorker)
 		if err != nil {
 			if err.Error() == "404" {
@@ -259,6 +302,20 @@ func updateTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		after := Task{TaskUUID: taskId, TodoListUUID: listId, OwnerUUID: aUser.UserUUID}
+		err = after.ReadOne(s.DbWorker)
+		if err != nil {
+			log.Error(service.TaskReadErr, err)
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindTask,
+			EntityUUID: taskId,
+			ListUUID:   listId,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"task id": task.TaskUUID,
@@ -289,9 +346,6 @@ func deleteTaskFunc(s *Service) http.HandlerFunc {
 		var aUser authUser
 		err := aUser.isAuth(w, r, s)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			log.Error(service.Unauthorized, err)
-			service.UnauthorizedResponse(w, "")
 			return
 		}
 
@@ -311,8 +365,25 @@ func deleteTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		archived, err := listIsArchived(s.DbWorker, listId, aUser.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.ListReadErr, err)
+			service.InternalServerErrorResponse(w, service.ListReadErr, err)
+			return
+		}
+		if archived {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.ListArchived, listId)
+			service.ConflictResponse(w, service.ListArchived)
+			return
+		}
+
 		t := Task{TodoListUUID: listId, TaskUUID: taskId, OwnerUUID: aUser.UserUUID}
-		err = t.Delete(s.DbWorker)
+		err = t.ReadOne(s.DbWorker)
+		if err == nil {
+			err = t.Delete(s.DbWorker)
+		}
 		if err != nil {
 			if err.Error() == "404" {
 				w.WriteHeader(http.StatusNotFound)
@@ -326,6 +397,14 @@ func deleteTaskFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  aUser.UserUUID,
+			EntityKind: audit.KindTask,
+			EntityUUID: taskId,
+			ListUUID:   listId,
+			Action:     audit.ActionDelete,
+		}, t, nil)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id": t.ID,
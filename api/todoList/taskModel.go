@@ -4,6 +4,24 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
+	"todoApp/types"
+)
+
+// Task statuses as used by the front end.
+const (
+	TaskStatusNew = iota
+	TaskStatusInProgress
+	TaskStatusCompleted
+	TaskStatusDraft
+)
+
+// Task priorities as used by the front end.
+const (
+	TaskPriorityLow = iota
+	TaskPriorityMiddle
+	TaskPriorityHi
+	TaskPriorityUrgently
+	TaskPriorityLater
 )
 
 type Task struct {
@@ -20,6 +38,8 @@ type Task struct {
 	Order        int        `json:"order"`
 	AddedDate    time.Time  `json:"addedDate" gorm:"column:created_at; autoCreateTime"`
 	OwnerUUID    uuid.UUID  `json:"-" gorm:"index"`
+	CompletedAt  *time.Time `json:"completedAt"`
+	TrackedTime  *int64     `json:"trackedTime,omitempty" gorm:"-"`
 }
 
 type createTask struct {
@@ -33,14 +53,22 @@ type createTask struct {
 	TodoListUUID uuid.UUID  `json:"-"`
 	TaskUUID     uuid.UUID  `json:"-"`
 	OwnerUUID    uuid.UUID  `json:"-"`
+	CompletedAt  *time.Time `json:"-"`
 }
 
 func (t *Task) Create(dbw dbWorker) error {
-	err := dbw.CreateRecord(t)
-	if err != nil {
-		return err
+	if t.Status == TaskStatusCompleted {
+		now := time.Now()
+		t.CompletedAt = &now
 	}
-	return nil
+
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.CreateRecord(t)
+		if err != nil {
+			return err
+		}
+		return saveTaskVersion(tx, *t)
+	})
 }
 
 func (t *Task) Read(dbw dbWorker, order string, count, page int) ([]Task, error) {
@@ -57,22 +85,66 @@ func (t *Task) Read(dbw dbWorker, order string, count, page int) ([]Task, error)
 	if err != nil {
 		return nil, err
 	}
+
+	err = addTrackedTime(dbw, tasks)
+	if err != nil {
+		return nil, err
+	}
 	return tasks, nil
 }
 
-func (c *createTask) Update(dbw dbWorker) error {
+func (t *Task) ReadOne(dbw dbWorker) error {
 	params := map[string]any{
-		"todo_list_uuid": c.TodoListUUID,
-		"task_uuid":      c.TaskUUID,
-		"owner_uuid":     c.OwnerUUID,
+		"todo_list_uuid": t.TodoListUUID,
+		"task_uuid":      t.TaskUUID,
+		"owner_uuid":     t.OwnerUUID,
 	}
-	err := dbw.UpdateRecordSubmodel(Task{}, c, params)
+	err := dbw.ReadOneRecord(t, params)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (c *createTask) Update(dbw dbWorker) error {
+	t := Task{TaskUUID: c.TaskUUID, TodoListUUID: c.TodoListUUID, OwnerUUID: c.OwnerUUID}
+	err := t.ReadOne(dbw)
+	if err != nil {
+		return err
+	}
+
+	// Completion time is kept while the task stays completed and reset once
+	// it is reopened.
+	switch {
+	case c.Status != TaskStatusCompleted:
+		c.CompletedAt = nil
+	case t.Status == TaskStatusCompleted && t.CompletedAt != nil:
+		c.CompletedAt = t.CompletedAt
+	default:
+		now := time.Now()
+		c.CompletedAt = &now
+	}
+
+	params := map[string]any{
+		"todo_list_uuid": c.TodoListUUID,
+		"task_uuid":      c.TaskUUID,
+		"owner_uuid":     c.OwnerUUID,
+	}
+	return dbw.Transaction(func(tx types.DatabaseWorker) error {
+		err := tx.UpdateRecordSubmodel(Task{}, c, params)
+		if err != nil {
+			return err
+		}
+
+		t := Task{TaskUUID: c.TaskUUID, TodoListUUID: c.TodoListUUID, OwnerUUID: c.OwnerUUID}
+		err = t.ReadOne(tx)
+		if err != nil {
+			return err
+		}
+		return saveTaskVersion(tx, t)
+	})
+}
+
 func (t *Task) Delete(dbw dbWorker) error {
 	params := map[string]any{
 		"todo_list_uuid": t.TodoListUUID,
@@ -0,0 +1,359 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/service"
+)
+
+// taskFromPath reads user's task given by taskId path value. On failure the
+// response is already written.
+func taskFromPath(w http.ResponseWriter, r *http.Request, s *Service, aUser authUser) (Task, bool) {
+	taskId, err := uuid.Parse(r.PathValue("taskId"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error(service.ParseErr, err)
+		service.BadRequestResponse(w, service.ParseErr, err)
+		return Task{}, false
+	}
+
+	t := Task{TaskUUID: taskId, OwnerUUID: aUser.UserUUID}
+	err = t.ReadByUUID(s.DbWorker)
+	if err != nil {
+		if err.Error() == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.DBNotFound)
+			service.NotFoundResponse(w, "")
+			return t, false
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.TaskReadErr, err)
+		service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+		return t, false
+	}
+	return t, true
+}
+
+// getTaskTimeFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get task time entries
+//	@Description	Requests time entries of the task and their total. Durations are in seconds, running timer counts up to now.
+//	@Tags			Time tracking
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Success		200		{object}	TaskTime				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/time [get]
+func getTaskTimeFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		t, ok := taskFromPath(w, r, s, aUser)
+		if !ok {
+			return
+		}
+
+		tt, err := readTaskTime(s.DbWorker, t)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryReadErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id": t.TaskUUID,
+		}).Info(service.TimeEntryReadSuccess)
+		service.OkResponse(w, tt)
+	}
+}
+
+// createTimeEntryFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Add time entry
+//	@Description	Adds time spent on the task manually. Time format example: "2024-01-02T15:04:05Z"
+//	@Tags			Time tracking
+//	@Accept			json
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Param			data	body		createTimeEntry			true	"Time entry"
+//	@Success		200		{object}	TimeEntry				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/time [post]
+func createTimeEntryFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createTimeEntry{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		t, ok := taskFromPath(w, r, s, aUser)
+		if !ok {
+			return
+		}
+
+		e, err := c.Create(s.DbWorker, t)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryCreateErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryCreateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id":  t.TaskUUID,
+			"duration": e.Duration,
+		}).Info(service.TimeEntryCreateSuccess)
+		service.OkResponse(w, e)
+	}
+}
+
+// startTimerFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Start timer
+//	@Description	Starts tracking time on the task. Only one timer per user can run, starting another one answers with 409.
+//	@Tags			Time tracking
+//	@Accept			json
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Param			data	body		startTimer				false	"Note"
+//	@Success		200		{object}	TimeEntry				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		409		{object}	service.errorResponse	"Conflict"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/time/start [post]
+func startTimerFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		st := startTimer{}
+		if len(data) > 0 {
+			err = service.DeserializeJSON(data, &st)
+			if err != nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				log.Error(service.JSONDeserializingErr, err)
+				service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+				return
+			}
+		}
+
+		err = st.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		t, ok := taskFromPath(w, r, s, aUser)
+		if !ok {
+			return
+		}
+
+		e, err := st.Start(s.DbWorker, t)
+		if err != nil {
+			if errors.Is(err, errTimerRunning) {
+				w.WriteHeader(http.StatusConflict)
+				log.Error(service.TimerRunningErr, aUser.UserUUID)
+				service.ConflictResponse(w, service.TimerRunningErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryCreateErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryCreateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id": t.TaskUUID,
+		}).Info(service.TimerStartSuccess)
+		service.OkResponse(w, e)
+	}
+}
+
+// stopTimerFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Stop timer
+//	@Description	Stops the running timer of the task
+//	@Tags			Time tracking
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Success		200		{object}	TimeEntry				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/time/stop [post]
+func stopTimerFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		t, ok := taskFromPath(w, r, s, aUser)
+		if !ok {
+			return
+		}
+
+		e := TimeEntry{TaskUUID: t.TaskUUID, UserUUID: aUser.UserUUID}
+		err = e.Stop(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryUpdateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"task id":  t.TaskUUID,
+			"duration": e.Duration,
+		}).Info(service.TimerStopSuccess)
+		service.OkResponse(w, e)
+	}
+}
+
+// deleteTimeEntryFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Delete time entry
+//	@Description	Deletes time entry of the task
+//	@Tags			Time tracking
+//	@Produce		json
+//	@Param			taskId	path		string					true	"Task UUID"
+//	@Param			entryId	path		string					true	"Time entry UUID"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/tasks/{taskId}/time/{entryId} [delete]
+func deleteTimeEntryFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		entryId, err := uuid.Parse(r.PathValue("entryId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		t, ok := taskFromPath(w, r, s, aUser)
+		if !ok {
+			return
+		}
+
+		e := TimeEntry{EntryUUID: entryId, TaskUUID: t.TaskUUID, UserUUID: aUser.UserUUID}
+		err = e.Delete(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TimeEntryDeleteErr, err)
+			service.InternalServerErrorResponse(w, service.TimeEntryDeleteErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": entryId,
+		}).Info(service.TimeEntryDeleteSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.TimeEntryDeleteSuccess,
+			Data:       "",
+		})
+	}
+}
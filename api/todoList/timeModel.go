@@ -0,0 +1,207 @@
+package todoList
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var errTimerRunning = errors.New("timer is already running")
+
+// TimeEntry is a period of work on a task. Entries of running timers have
+// no end. A user can run only one timer at a time, the partial unique
+// index guards it against concurrent starts.
+type TimeEntry struct {
+	ID           uint       `json:"-" gorm:"primarykey"`
+	EntryUUID    uuid.UUID  `json:"id" gorm:"index" extensions:"x-order=1"`
+	TaskUUID     uuid.UUID  `json:"taskId" gorm:"index" extensions:"x-order=2"`
+	TodoListUUID uuid.UUID  `json:"-" gorm:"index"`
+	UserUUID     uuid.UUID  `json:"-" gorm:"index;uniqueIndex:idx_running_timer,where:ended_at IS NULL"`
+	StartedAt    time.Time  `json:"start" extensions:"x-order=3"`
+	EndedAt      *time.Time `json:"end" extensions:"x-order=4"`
+	Note         string     `json:"note" extensions:"x-order=5"`
+	Duration     int64      `json:"duration" gorm:"-" extensions:"x-order=6"`
+	CreatedAt    time.Time  `json:"-"`
+	UpdatedAt    time.Time  `json:"-"`
+}
+
+type createTimeEntry struct {
+	StartedAt *time.Time `json:"start" extensions:"x-order=1"`
+	EndedAt   *time.Time `json:"end" extensions:"x-order=2"`
+	Note      string     `json:"note" extensions:"x-order=3"`
+}
+
+type startTimer struct {
+	Note string `json:"note" extensions:"x-order=1"`
+}
+
+type stopTimer struct {
+	EndedAt *time.Time
+}
+
+// TaskTime lists time entries of a task. Durations are in seconds, running
+// timers count up to now.
+type TaskTime struct {
+	Total   int64       `json:"total"`
+	Entries []TimeEntry `json:"entries"`
+}
+
+type trackedTime struct {
+	ID      uuid.UUID
+	Seconds float64
+}
+
+const maxNoteLength = 1000
+
+func (c *createTimeEntry) validate() error {
+	if c.StartedAt == nil || c.EndedAt == nil {
+		return errors.New("start and end are required")
+	}
+	if !c.EndedAt.After(*c.StartedAt) {
+		return errors.New("end has to be after start")
+	}
+	if c.EndedAt.After(time.Now()) {
+		return errors.New("end can't be in the future")
+	}
+	if len([]rune(c.Note)) > maxNoteLength {
+		return errors.New("note is too long (MAX=1000)")
+	}
+	return nil
+}
+
+func (st *startTimer) validate() error {
+	if len([]rune(st.Note)) > maxNoteLength {
+		return errors.New("note is too long (MAX=1000)")
+	}
+	return nil
+}
+
+func (e *TimeEntry) setDuration(now time.Time) {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	e.Duration = int64(end.Sub(e.StartedAt).Seconds())
+}
+
+func (c *createTimeEntry) Create(dbw dbWorker, t Task) (TimeEntry, error) {
+	e := TimeEntry{
+		EntryUUID:    uuid.New(),
+		TaskUUID:     t.TaskUUID,
+		TodoListUUID: t.TodoListUUID,
+		UserUUID:     t.OwnerUUID,
+		StartedAt:    *c.StartedAt,
+		EndedAt:      c.EndedAt,
+		Note:         c.Note,
+	}
+	err := dbw.CreateRecord(&e)
+	if err != nil {
+		return e, err
+	}
+	e.setDuration(time.Now())
+	return e, nil
+}
+
+// Start runs a new timer on the task unless the user has one running.
+// idx_running_timer decides between parallel starts, the insert that loses
+// is skipped and reported as errTimerRunning.
+func (st *startTimer) Start(dbw dbWorker, t Task) (TimeEntry, error) {
+	e := TimeEntry{
+		EntryUUID:    uuid.New(),
+		TaskUUID:     t.TaskUUID,
+		TodoListUUID: t.TodoListUUID,
+		UserUUID:     t.OwnerUUID,
+		StartedAt:    time.Now(),
+		Note:         st.Note,
+	}
+
+	err := dbw.CreateIfMissing(&e)
+	if err != nil {
+		if err.Error() == "409" {
+			return e, errTimerRunning
+		}
+		return e, err
+	}
+	return e, nil
+}
+
+// Stop ends the running timer of the task.
+func (e *TimeEntry) Stop(dbw dbWorker) error {
+	params := map[string]any{"task_uuid": e.TaskUUID, "user_uuid": e.UserUUID, "ended_at IS": nil}
+	err := dbw.ReadOneRecord(e, params)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = dbw.UpdateRecordSubmodel(TimeEntry{}, &stopTimer{EndedAt: &now}, map[string]any{"id": e.ID})
+	if err != nil {
+		return err
+	}
+	e.EndedAt = &now
+	e.setDuration(now)
+	return nil
+}
+
+func (e *TimeEntry) Delete(dbw dbWorker) error {
+	params := map[string]any{"entry_uuid": e.EntryUUID, "task_uuid": e.TaskUUID, "user_uuid": e.UserUUID}
+	return dbw.DeleteRecord(&TimeEntry{}, params)
+}
+
+func readTaskTime(dbw dbWorker, t Task) (TaskTime, error) {
+	result := TaskTime{Entries: []TimeEntry{}}
+	params := map[string]any{"task_uuid": t.TaskUUID, "order": "asc", "sort_by": "started_at"}
+	err := dbw.ReadManyRecords(TimeEntry{}, &result.Entries, params)
+	if err != nil && err.Error() != "404" {
+		return result, err
+	}
+
+	now := time.Now()
+	for i := range result.Entries {
+		result.Entries[i].setDuration(now)
+		result.Total += result.Entries[i].Duration
+	}
+	return result, nil
+}
+
+// addTrackedTime fills TrackedTime of the tasks. Tasks that are not passed
+// through here leave it out of the JSON instead of claiming zero.
+func addTrackedTime(dbw dbWorker, tasks []Task) error {
+	ids := make([]uuid.UUID, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].TaskUUID
+	}
+	totals, err := sumTrackedTime(dbw, "task_uuid", ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		total := totals[tasks[i].TaskUUID]
+		tasks[i].TrackedTime = &total
+	}
+	return nil
+}
+
+// sumTrackedTime returns seconds tracked per task or per list, depending on
+// the column entries are grouped by.
+func sumTrackedTime(dbw dbWorker, column string, ids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	totals := make(map[uuid.UUID]int64)
+	if len(ids) == 0 {
+		return totals, nil
+	}
+
+	var rows []trackedTime
+	err := dbw.Aggregate(TimeEntry{}, &rows, map[string]any{
+		"select":       column + " AS id, sum(extract(epoch from coalesce(ended_at, now()) - started_at)) AS seconds",
+		"group":        column,
+		column + " IN": ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		totals[row.ID] = int64(row.Seconds)
+	}
+	return totals, nil
+}
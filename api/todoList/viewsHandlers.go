@@ -0,0 +1,173 @@
+package todoList
+
+import (
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"time"
+	"todoApp/api/service"
+	"todoApp/api/user"
+)
+
+// viewLocation returns the time zone views are computed in: tz query param
+// if given, user's time zone from settings otherwise.
+func viewLocation(r *http.Request, s *Service, aUser authUser) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz != "" {
+		return time.LoadLocation(tz)
+	}
+
+	st := user.UserSettings{UserUUID: aUser.UserUUID}
+	err := st.Read(s.DbWorker)
+	if err != nil {
+		return nil, err
+	}
+	return st.Location(), nil
+}
+
+// getTodayViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get tasks for today
+//	@Description	Requests unfinished tasks of all active lists with deadline or start date today, grouped by list
+//	@Tags			Views
+//	@Produce		json
+//	@Param			tz	query		string					false	"IANA time zone, e.g. Europe/Berlin (default from user settings)"
+//	@Success		200	{array}		ViewGroup				"OK"
+//	@Failure		400	{object}	service.errorResponse	"Bad request"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/today [get]
+func getTodayViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		loc, err := viewLocation(r, s, aUser)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		groups, err := readView(s.DbWorker, aUser, todayFilters(time.Now(), loc))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"user": aUser.UserUUID,
+		}).Info(service.ViewReadSuccess)
+		service.OkResponse(w, groups)
+	}
+}
+
+// getUpcomingViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get upcoming tasks
+//	@Description	Requests unfinished tasks of all active lists with deadline or start date during the next days (today excluded), grouped by list. Default: days=7
+//	@Tags			Views
+//	@Produce		json
+//	@Param			days	query		string					false	"Number of days to look ahead"
+//	@Param			tz		query		string					false	"IANA time zone, e.g. Europe/Berlin (default from user settings)"
+//	@Success		200		{array}		ViewGroup				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/upcoming [get]
+func getUpcomingViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		loc, err := viewLocation(r, s, aUser)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ParseErr, err)
+			service.BadRequestResponse(w, service.ParseErr, err)
+			return
+		}
+
+		days := 7
+		if r.URL.Query().Has("days") {
+			days, err = strconv.Atoi(r.URL.Query().Get("days"))
+			if err == nil && (days < 1 || days > 365) {
+				err = errors.New("days must be between 1 and 365")
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				log.Error(service.ParseErr, err)
+				service.BadRequestResponse(w, service.ParseErr, err)
+				return
+			}
+		}
+
+		groups, err := readView(s.DbWorker, aUser, upcomingFilters(time.Now(), loc, days))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"user": aUser.UserUUID,
+		}).Info(service.ViewReadSuccess)
+		service.OkResponse(w, groups)
+	}
+}
+
+// getOverdueViewFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get overdue tasks
+//	@Description	Requests unfinished tasks of all active lists with deadline in the past, grouped by list
+//	@Tags			Views
+//	@Produce		json
+//	@Success		200	{array}		ViewGroup				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/views/overdue [get]
+func getOverdueViewFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var aUser authUser
+		err := aUser.isAuth(w, r, s)
+		if err != nil {
+			return
+		}
+
+		groups, err := readView(s.DbWorker, aUser, overdueFilters(time.Now()))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TaskReadErr, err)
+			service.InternalServerErrorResponse(w, service.TaskReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"user": aUser.UserUUID,
+		}).Info(service.ViewReadSuccess)
+		service.OkResponse(w, groups)
+	}
+}
@@ -0,0 +1,115 @@
+package todoList
+
+import (
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+// ViewGroup is a part of a smart view holding the tasks of one list.
+type ViewGroup struct {
+	List  readTodoList `json:"list"`
+	Tasks []Task       `json:"tasks"`
+}
+
+// dayStart returns midnight of the day t falls on in loc.
+func dayStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// todayFilters match unfinished tasks that are due or start on the current
+// day of the user.
+func todayFilters(now time.Time, loc *time.Location) []map[string]any {
+	from := dayStart(now, loc)
+	return windowFilters(from, from.AddDate(0, 0, 1))
+}
+
+// upcomingFilters match unfinished tasks that are due or start during the
+// next days, today excluded.
+func upcomingFilters(now time.Time, loc *time.Location, days int) []map[string]any {
+	from := dayStart(now, loc).AddDate(0, 0, 1)
+	return windowFilters(from, from.AddDate(0, 0, days))
+}
+
+func overdueFilters(now time.Time) []map[string]any {
+	return []map[string]any{{"deadline <": now}}
+}
+
+func windowFilters(from, to time.Time) []map[string]any {
+	return []map[string]any{
+		{"deadline >=": from, "deadline <": to},
+		{"start_date >=": from, "start_date <": to},
+	}
+}
+
+// readView collects unfinished tasks of the active user's lists matching
+// any of the filters and groups them by list.
+func readView(dbw dbWorker, aUser authUser, filters []map[string]any) ([]ViewGroup, error) {
+	var r readTodoList
+	lists, err := r.GetAllLists(dbw, aUser, "asc", false)
+	if err != nil {
+		if err.Error() == "404" {
+			return []ViewGroup{}, nil
+		}
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	byList := make(map[uuid.UUID][]Task)
+	for _, f := range filters {
+		var tasks []Task
+		params := map[string]any{
+			"owner_uuid": aUser.UserUUID,
+			"status <>":  TaskStatusCompleted,
+		}
+		for k, v := range f {
+			params[k] = v
+		}
+
+		err = dbw.ReadManyRecords(Task{}, &tasks, params)
+		if err != nil {
+			if err.Error() == "404" {
+				continue
+			}
+			return nil, err
+		}
+		err = addTrackedTime(dbw, tasks)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tasks {
+			if seen[t.TaskUUID] {
+				continue
+			}
+			seen[t.TaskUUID] = true
+			byList[t.TodoListUUID] = append(byList[t.TodoListUUID], t)
+		}
+	}
+
+	groups := []ViewGroup{}
+	for _, l := range lists {
+		tasks, ok := byList[l.ListUuid]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return viewDate(tasks[i]).Before(viewDate(tasks[j]))
+		})
+		groups = append(groups, ViewGroup{List: l, Tasks: tasks})
+	}
+	return groups, nil
+}
+
+// viewDate is the date a task is sorted by in views: its deadline, or its
+// start date when there is no deadline.
+func viewDate(t Task) time.Time {
+	if t.Deadline != nil {
+		return *t.Deadline
+	}
+	if t.StartDate != nil {
+		return *t.StartDate
+	}
+	return time.Time{}
+}
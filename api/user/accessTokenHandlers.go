@@ -0,0 +1,218 @@
+package user
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// createAccessTokenFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Create personal access token
+//	@Description	Creates a token for scripts and CLI tools, send it as "Authorization: Bearer <token>". Scope is read or write, expiresInDays is 1-365 (default 30). The token is shown only once. Tokens can't be managed with tokens, log in for that.
+//	@Tags			Access tokens
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		createAccessToken		true	"Token"
+//	@Success		201		{object}	createdAccessToken		"Created"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/tokens [post]
+func createAccessTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createAccessToken{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		t := AccessToken{UserUUID: session.UserUuid}
+		plain, err := t.Create(s.DbWorker, c)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.AccessTokenCreateErr, err)
+			service.InternalServerErrorResponse(w, service.AccessTokenCreateErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindSession,
+			EntityUUID: t.TokenUUID,
+			Action:     audit.ActionCreate,
+		}, nil, t)
+
+		w.WriteHeader(http.StatusCreated)
+		log.WithFields(log.Fields{
+			"id":    t.TokenUUID,
+			"scope": t.Scope,
+		}).Info(service.AccessTokenCreateSuccess)
+		service.OkResponse(w, createdAccessToken{AccessToken: t, Token: plain})
+	}
+}
+
+// getAccessTokensFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get personal access tokens
+//	@Description	Requests user's personal access tokens, the token values themselves are not stored
+//	@Tags			Access tokens
+//	@Produce		json
+//	@Success		200	{array}		AccessToken				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/tokens [get]
+func getAccessTokensFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		t := AccessToken{UserUUID: session.UserUuid}
+		tokens, err := t.ReadAll(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.AccessTokenReadErr, err)
+			service.InternalServerErrorResponse(w, service.AccessTokenReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.AccessTokenReadSuccess)
+		service.OkResponse(w, tokens)
+	}
+}
+
+// deleteAccessTokenFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Revoke personal access token
+//	@Description	Revokes one of user's personal access tokens
+//	@Tags			Access tokens
+//	@Produce		json
+//	@Param			tokenId	path		string					true	"token uuid"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/tokens/{tokenId} [delete]
+func deleteAccessTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		tokenUuid, err := uuid.Parse(r.PathValue("tokenId"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.UUIDParseErr, err)
+			service.BadRequestResponse(w, service.UUIDParseErr, err)
+			return
+		}
+
+		t := AccessToken{TokenUUID: tokenUuid, UserUUID: session.UserUuid}
+		err = t.Delete(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.AccessTokenDeleteErr, err)
+			service.InternalServerErrorResponse(w, service.AccessTokenDeleteErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindSession,
+			EntityUUID: t.TokenUUID,
+			Action:     audit.ActionDelete,
+		}, t, nil)
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": t.TokenUUID,
+		}).Info(service.AccessTokenDeleteSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.AccessTokenDeleteSuccess,
+			Data:       nil,
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"todoApp/types"
+)
+
+const (
+	accessTokenPrefix     = "tdp_"
+	accessTokenDefaultTTL = 30
+	accessTokenMaxTTL     = 365
+)
+
+// AccessToken is a personal access token for scripts and CLI tools. It is
+// sent as "Authorization: Bearer" and only its hash is stored.
+type AccessToken struct {
+	ID         uint       `gorm:"primarykey" json:"-"`
+	TokenUUID  uuid.UUID  `gorm:"uniqueIndex" json:"id" extensions:"x-order=1"`
+	UserUUID   uuid.UUID  `gorm:"index" json:"-"`
+	Name       string     `json:"name" extensions:"x-order=2"`
+	Scope      string     `json:"scope" example:"read" extensions:"x-order=3"`
+	TokenHash  string     `gorm:"uniqueIndex" json:"-"`
+	Hint       string     `json:"hint" extensions:"x-order=4"`
+	ExpiresAt  time.Time  `json:"expires" extensions:"x-order=5"`
+	LastUsedAt *time.Time `json:"lastUsed" extensions:"x-order=6"`
+	CreatedAt  time.Time  `json:"created" extensions:"x-order=7"`
+}
+
+type createAccessToken struct {
+	Name          string `json:"name" example:"backup script" extensions:"x-order=1"`
+	Scope         string `json:"scope" example:"read" extensions:"x-order=2"`
+	ExpiresInDays int    `json:"expiresInDays" example:"30" extensions:"x-order=3"`
+}
+
+type createdAccessToken struct {
+	AccessToken
+	Token string `json:"token" extensions:"x-order=0"`
+}
+
+type accessTokenUsed struct {
+	LastUsedAt *time.Time
+}
+
+func (c *createAccessToken) validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+	if c.Scope != types.ScopeRead && c.Scope != types.ScopeWrite {
+		return errors.New("scope must be read or write")
+	}
+	if c.ExpiresInDays == 0 {
+		c.ExpiresInDays = accessTokenDefaultTTL
+	}
+	if c.ExpiresInDays < 1 || c.ExpiresInDays > accessTokenMaxTTL {
+		return errors.New("expiresInDays must be 1-365")
+	}
+	return nil
+}
+
+// isAccessToken tells personal access tokens apart from session tokens.
+func isAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+// Create issues a token for the user and returns its plain value.
+func (t *AccessToken) Create(wrk dbWorker, c createAccessToken) (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	token := accessTokenPrefix + hex.EncodeToString(bytes)
+
+	t.TokenUUID = uuid.New()
+	t.Name = c.Name
+	t.Scope = c.Scope
+	t.TokenHash = hashKey(token)
+	t.Hint = token[len(token)-4:]
+	t.ExpiresAt = time.Now().AddDate(0, 0, c.ExpiresInDays)
+
+	err = wrk.CreateRecord(t)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Find loads an unexpired token by its plain value and notes its use.
+func (t *AccessToken) Find(wrk dbWorker, token string) error {
+	err := wrk.ReadOneRecord(t, map[string]any{"token_hash": hashKey(token)})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.After(t.ExpiresAt) {
+		return errors.New("404")
+	}
+
+	err = wrk.UpdateRecordSubmodel(AccessToken{}, &accessTokenUsed{LastUsedAt: &now}, map[string]any{"id": t.ID})
+	if err != nil {
+		return err
+	}
+	t.LastUsedAt = &now
+	return nil
+}
+
+func (t *AccessToken) ReadAll(wrk dbWorker) ([]AccessToken, error) {
+	tokens := []AccessToken{}
+	params := map[string]any{"user_uuid": t.UserUUID, "order": "desc", "sort_by": "created_at"}
+	err := wrk.ReadManyRecords(AccessToken{}, &tokens, params)
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Delete revokes the token, the user can revoke only their own.
+func (t *AccessToken) Delete(wrk dbWorker) error {
+	params := map[string]any{"token_uuid": t.TokenUUID, "user_uuid": t.UserUUID}
+	err := wrk.ReadOneRecord(t, params)
+	if err != nil {
+		return err
+	}
+	return wrk.DeleteRecord(&AccessToken{}, map[string]any{"id": t.ID})
+}
+
+// DeleteAll removes every token of t.UserUUID.
+func (t *AccessToken) DeleteAll(wrk dbWorker) error {
+	err := wrk.DeleteRecord(&AccessToken{}, map[string]any{"user_uuid": t.UserUUID})
+	if err != nil && err.Error() == "404" {
+		return nil
+	}
+	return err
+}
@@ -1,10 +1,15 @@
 package user
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"todoApp/api/audit"
 	"todoApp/api/service"
 )
 
@@ -24,7 +29,7 @@ func meFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		token, err := r.Cookie(service.SessionTokenName)
+		token, err := service.ReadToken(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.TokenReadErr, err)
@@ -32,8 +37,7 @@ func meFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
-		session := Session{Token: token.Value}
-		err = session.Read(s.DbWorker)
+		session, err := readSession(s, token)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.TokenValidationErr, err)
@@ -73,15 +77,16 @@ func meFunc(s *Service) http.HandlerFunc {
 // loginFunc     godoc
 //
 //	@Summary		Log in
-//	@Description	Success login gives you a cookie with access token
+//	@Description	Success login gives you a cookie with access token. With two-factor authentication on, it gives a challenge token for /login/2fa instead. Unknown email and wrong password get the same 401. Repeated failures for an email or from an IP make the next attempts wait (429 with Retry-After), the owner of a locked account gets an unlock link by email.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			user	body		loginUserModel			true	"login"
 //	@Success		200		{object}	service.DefaultResponse	"OK"
 //	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
 //	@Failure		403		{object}	service.errorResponse	"Forbidden"
-//	@Failure		404		{object}	service.errorResponse	"Not found"
+//	@Failure		429		{object}	service.errorResponse	"Too many requests"
 //	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
 //	@Failure		500		{object}	service.errorResponse	"Internal server error"
 //	@Router			/login [post]
@@ -114,62 +119,387 @@ func loginFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		now := time.Now()
+		account := LoginFailure{Key: accountKey(usr.Email)}
+		ip := LoginFailure{Key: ipKey(r, s.Config.Config.TrustedProxyHeader)}
+		if claimLogin(w, s, &account, &ip, now) {
+			return
+		}
+
 		getUsr := User{Email: usr.Email}
 		err = getUsr.Read(s.DbWorker)
-		if err != nil {
-			if err.Error() == "404" {
-				w.WriteHeader(http.StatusNotFound)
-				log.Debug(service.EmailNotFoundErr)
-				service.NotFoundResponse(w, service.EmailNotFoundErr)
-				return
-			}
-			w.WriteHeader(http.StatusBadRequest)
-			log.Error(service.EmailErr, err)
-			service.BadRequestResponse(w, service.EmailErr, err)
+		if err != nil && err.Error() != "404" {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+		exists := err == nil
+
+		// Unknown emails cost a bcrypt run too, so timing doesn't tell
+		// them apart from wrong passwords.
+		hashed := dummyPasswordHash()
+		if exists {
+			hashed = getUsr.Password
+		}
+		err = comparePasswords(hashed, usr.Password)
+		if err != nil || !exists {
+			loginFailed(w, s, &account, &ip, getUsr, exists)
 			return
 		}
+		releaseLoginIP(s, &ip)
 
 		if !getUsr.EmailVerified {
 			w.WriteHeader(http.StatusForbidden)
-			log.Error(service.EmailNotVerified, err)
+			log.Error(service.EmailNotVerified)
 			service.ForbiddenResponse(w, service.EmailNotVerified)
 			return
 		}
 
-		err = comparePasswords(getUsr.Password, usr.Password)
+		finishLogin(w, r, s, getUsr)
+	}
+}
+
+// dummyPasswordHash is compared against when the email is unknown.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hashed, err := hashPassword("not a real password")
+	if err != nil {
+		log.Error(service.HashPasswordErr, err)
+	}
+	return hashed
+})
+
+// claimLogin reserves the attempt on the counters of the IP and the email
+// before any credential is checked, and answers with 429 while either of
+// them waits out a backoff. The reservation counts as a failure until the
+// attempt turns out to be right.
+func claimLogin(w http.ResponseWriter, s *Service, account, ip *LoginFailure, now time.Time) bool {
+	wait, err := ip.Claim(s.DbWorker, ipThrottle, now)
+	if err == nil && wait == 0 {
+		wait, err = account.Claim(s.DbWorker, accountThrottle, now)
+		if err != nil || wait > 0 {
+			releaseLoginIP(s, ip)
+		}
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.LoginThrottleErr, err)
+		service.InternalServerErrorResponse(w, service.LoginThrottleErr, err)
+		return true
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		w.WriteHeader(http.StatusTooManyRequests)
+		log.WithFields(log.Fields{
+			"account": account.Key,
+			"ip":      ip.Key,
+		}).Warn(service.LoginLockedErr)
+		service.TooManyRequestsResponse(w, service.LoginLockedErr)
+		return true
+	}
+	return false
+}
+
+// loginFailed answers with the same error whether the email exists or not.
+// The failure was already counted by claimLogin.
+func loginFailed(w http.ResponseWriter, s *Service, account, ip *LoginFailure, usr User, exists bool) {
+	if exists {
+		warnLockedOut(s, account, usr)
+	}
+
+	w.WriteHeader(http.StatusUnauthorized)
+	log.WithFields(log.Fields{
+		"account":  account.Key,
+		"ip":       ip.Key,
+		"failures": account.Failures,
+	}).Warn(service.LoginErr)
+	service.UnauthorizedResponse(w, service.LoginErr)
+}
+
+// warnLockedOut sends the owner an unlock link when the failed attempt was
+// the one that reached accountLockoutAt.
+func warnLockedOut(s *Service, account *LoginFailure, usr User) {
+	if account.Failures != accountLockoutAt {
+		return
+	}
+	go func(f LoginFailure) {
+		err := sendUnlockLink(s, usr, &f)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"id": usr.UserUUID,
+			}).Error(service.UnlockErr, err)
+		}
+	}(*account)
+}
+
+// releaseLoginIP gives the IP its reserved attempt back once the
+// credentials were right. The account keeps it until a session is issued.
+func releaseLoginIP(s *Service, ip *LoginFailure) {
+	err := ip.Release(s.DbWorker, ipThrottle)
+	if err != nil {
+		log.Error(service.LoginThrottleErr, err)
+	}
+}
+
+// forgetLoginFailures clears the account's failed logins once a session is
+// issued, not before the second factor is passed.
+func forgetLoginFailures(s *Service, usr User) {
+	account := LoginFailure{Key: accountKey(usr.Email)}
+	err := account.Reset(s.DbWorker)
+	if err != nil {
+		log.Error(service.LoginThrottleErr, err)
+	}
+}
+
+// finishLogin runs after the user has proven who they are: asks for the
+// second factor when 2FA is on, starts the session otherwise.
+func finishLogin(w http.ResponseWriter, r *http.Request, s *Service, usr User) {
+	tf := TwoFactor{UserUUID: usr.UserUUID}
+	enabled, err := tf.IsEnabled(s.DbWorker)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.TwoFactorErr, err)
+		service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+		return
+	}
+
+	if enabled {
+		challenge := LoginChallenge{UserUUID: usr.UserUUID}
+		token, err := challenge.Create(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": usr.UserUUID,
+		}).Info(service.TwoFactorRequired)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.TwoFactorRequired,
+			Data: challengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    token,
+				Expires:           challenge.ExpiresAt,
+			},
+		})
+		return
+	}
+
+	startSession(w, r, s, usr)
+}
+
+// startSession logs the user in: creates a session and sets its cookie or,
+// in JWT mode, issues an access and refresh token pair.
+func startSession(w http.ResponseWriter, r *http.Request, s *Service, usr User) {
+	if s.jwt != nil {
+		startTokenSession(w, r, s, usr)
+		return
+	}
+
+	var session Session
+	cookie, err := session.Create(s.DbWorker, usr.UserUUID, s.Salt, r.UserAgent())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.SessionCreateErr, err)
+		service.InternalServerErrorResponse(w, service.SessionCreateErr, err)
+		return
+	}
+
+	type uuidOnly struct {
+		UUID uuid.UUID `json:"userId"`
+	}
+
+	audit.Record(s.DbWorker, r, audit.Event{
+		ActorUUID:  usr.UserUUID,
+		EntityKind: audit.KindSession,
+		EntityUUID: usr.UserUUID,
+		Action:     audit.ActionCreate,
+	}, nil, session)
+
+	forgetLoginFailures(s, usr)
+
+	http.SetCookie(w, &cookie)
+	w.WriteHeader(http.StatusOK)
+
+	log.WithFields(log.Fields{
+		"id":       usr.UserUUID,
+		"username": usr.Username,
+	}).Info(service.LoginSuccess)
+
+	service.OkResponse(w, service.DefaultResponse{
+		ResultCode: 0,
+		HttpCode:   http.StatusOK,
+		Messages:   "",
+		Data:       uuidOnly{UUID: usr.UserUUID},
+	})
+}
+
+func startTokenSession(w http.ResponseWriter, r *http.Request, s *Service, usr User) {
+	var session Session
+	refresh, err := session.CreateRefresh(s.DbWorker, usr.UserUUID, s.Salt, r.UserAgent(), s.jwt.refreshTTL)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.SessionCreateErr, err)
+		service.InternalServerErrorResponse(w, service.SessionCreateErr, err)
+		return
+	}
+
+	access, _, err := s.jwt.issue(usr, *session.Family)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Error(service.SessionCreateErr, err)
+		service.InternalServerErrorResponse(w, service.SessionCreateErr, err)
+		return
+	}
+
+	audit.Record(s.DbWorker, r, audit.Event{
+		ActorUUID:  usr.UserUUID,
+		EntityKind: audit.KindSession,
+		EntityUUID: usr.UserUUID,
+		Action:     audit.ActionCreate,
+	}, nil, session)
+
+	forgetLoginFailures(s, usr)
+
+	w.WriteHeader(http.StatusOK)
+	log.WithFields(log.Fields{
+		"id":       usr.UserUUID,
+		"username": usr.Username,
+	}).Info(service.LoginSuccess)
+
+	service.OkResponse(w, service.DefaultResponse{
+		ResultCode: 0,
+		HttpCode:   http.StatusOK,
+		Messages:   "",
+		Data: tokenPair{
+			UserUUID:     usr.UserUUID,
+			AccessToken:  access,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(s.jwt.accessTTL.Seconds()),
+			RefreshToken: refresh,
+		},
+	})
+}
+
+// readSession finds the session behind a session token or, in JWT mode, the
+// refresh token family behind an access token.
+func readSession(s *Service, token string) (Session, error) {
+	if s.jwt != nil && isJWT(token) {
+		claims, err := s.jwt.verify(token, time.Now())
+		if err != nil {
+			return Session{}, err
+		}
+		session := Session{Family: &claims.SessionID}
+		err = session.ReadFamily(s.DbWorker)
+		return session, err
+	}
+
+	session := Session{Token: token}
+	err := session.Read(s.DbWorker)
+	return session, err
+}
+
+// refreshTokenFunc     godoc
+//
+//	@Summary		Refresh access token
+//	@Description	Only in JWT mode. Exchanges a refresh token for a new access and refresh token pair, the old refresh token stops working. Presenting an already used refresh token logs that login out everywhere.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		refreshModel			true	"Refresh token"
+//	@Success		200		{object}	tokenPair				"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/token/refresh [post]
+func refreshTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if s.jwt == nil {
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.JWTModeOffErr)
+			service.NotFoundResponse(w, service.JWTModeOffErr)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			log.Error(service.PasswordErr, err)
-			service.BadRequestResponse(w, service.PasswordErr, err)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		rm := refreshModel{}
+		err = service.DeserializeJSON(data, &rm)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
 			return
 		}
 
 		var session Session
-		cookie, err := session.Create(s.DbWorker, getUsr.UserUUID, s.Salt, r.UserAgent())
+		refresh, err := session.Rotate(s.DbWorker, rm.RefreshToken, s.Salt, s.jwt.refreshTTL)
 		if err != nil {
+			if errors.Is(err, errRefreshReuse) {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.WithFields(log.Fields{
+					"family": session.Family,
+				}).Warn(service.RefreshReuseErr)
+				service.UnauthorizedResponse(w, service.InvalidTokenErr)
+				return
+			}
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.Error(service.InvalidTokenErr)
+				service.UnauthorizedResponse(w, service.InvalidTokenErr)
+				return
+			}
 			w.WriteHeader(http.StatusInternalServerError)
 			log.Error(service.SessionCreateErr, err)
 			service.InternalServerErrorResponse(w, service.SessionCreateErr, err)
 			return
 		}
 
-		type uuidOnly struct {
-			UUID uuid.UUID `json:"userId"`
+		usr := User{UserUUID: session.UserUuid}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
 		}
 
-		http.SetCookie(w, &cookie)
-		w.WriteHeader(http.StatusOK)
+		access, _, err := s.jwt.issue(usr, *session.Family)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SessionCreateErr, err)
+			service.InternalServerErrorResponse(w, service.SessionCreateErr, err)
+			return
+		}
 
+		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
-			"id":       getUsr.UserUUID,
-			"username": getUsr.Username,
-		}).Info(service.LoginSuccess)
-
-		service.OkResponse(w, service.DefaultResponse{
-			ResultCode: 0,
-			HttpCode:   http.StatusOK,
-			Messages:   "",
-			Data:       uuidOnly{UUID: getUsr.UserUUID},
+			"id": usr.UserUUID,
+		}).Info(service.TokenRefreshSuccess)
+
+		service.OkResponse(w, tokenPair{
+			UserUUID:     usr.UserUUID,
+			AccessToken:  access,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(s.jwt.accessTTL.Seconds()),
+			RefreshToken: refresh,
 		})
 	}
 }
@@ -178,7 +508,7 @@ func loginFunc(s *Service) http.HandlerFunc {
 //
 //	@Security		BasicAuth
 //	@Summary		Log out
-//	@Description	Log out and invalidate access token
+//	@Description	Log out and invalidate access token. In JWT mode it revokes the refresh token, access tokens already issued stay valid until they expire.
 //	@Tags			Auth
 //	@Success		204
 //	@Failure		400	{object}	service.errorResponse	"Bad request"
@@ -187,7 +517,7 @@ func loginFunc(s *Service) http.HandlerFunc {
 //	@Router			/logout [post]
 func logoutFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(service.SessionTokenName)
+		token, err := service.ReadToken(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			log.Error(service.CookieReadErr, err)
@@ -195,8 +525,15 @@ func logoutFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
-		session := Session{Token: cookie.Value}
-		err = session.Delete(s.DbWorker)
+		session := Session{Token: token}
+		if s.jwt != nil && isJWT(token) {
+			session, err = readSession(s, token)
+			if err == nil {
+				err = session.DeleteFamily(s.DbWorker)
+			}
+		} else {
+			err = session.Delete(s.DbWorker)
+		}
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.InvalidTokenErr)
@@ -204,9 +541,16 @@ func logoutFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindSession,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionDelete,
+		}, session, nil)
+
 		w.WriteHeader(http.StatusNoContent)
 		log.WithFields(log.Fields{
-			"session": cookie.Value,
+			"session": token,
 		}).Info(service.LogoutSuccess)
 	}
 }
@@ -227,7 +571,7 @@ func getAllSessionsFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		token, err := r.Cookie(service.SessionTokenName)
+		token, err := service.ReadToken(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.TokenReadErr, err)
@@ -235,8 +579,7 @@ func getAllSessionsFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
-		session := Session{Token: token.Value}
-		err = session.Read(s.DbWorker)
+		session, err := readSession(s, token)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.TokenValidationErr, err)
@@ -278,7 +621,7 @@ func closeOtherSessionsFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		token, err := r.Cookie(service.SessionTokenName)
+		token, err := service.ReadToken(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			log.Error(service.CookieReadErr, err)
@@ -286,8 +629,7 @@ func closeOtherSessionsFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
-		session := Session{Token: token.Value}
-		err = session.Read(s.DbWorker)
+		session, err := readSession(s, token)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			log.Error(service.TokenValidationErr, err)
@@ -295,7 +637,7 @@ func closeOtherSessionsFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
-		err = session.DeleteAllExceptOne(s.DbWorker, session.Token)
+		err = session.DeleteOthers(s.DbWorker)
 		if err != nil {
 			if err.Error() == "404" {
 				w.WriteHeader(http.StatusNoContent)
@@ -308,6 +650,13 @@ func closeOtherSessionsFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindSession,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionDelete,
+		}, nil, nil)
+
 		w.WriteHeader(http.StatusNoContent)
 		log.Info(service.SessionsCloseSuccess)
 	}
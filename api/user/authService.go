@@ -1,23 +1,78 @@
 package user
 
-import "todoApp/types"
+import (
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"todoApp/config"
+	"todoApp/types"
+)
 
+// AuthService is shared by all requests, so it keeps nothing but the JWT
+// keys read at start.
 type AuthService struct {
-	types.AuthUser
+	jwt *jwtIssuer
 }
 
-func (a *AuthService) IsUserLoggedIn(dbw types.DatabaseWorker, tokenValue string) (types.AuthUser, error) {
-	s := Session{Token: tokenValue}
-	err := s.Read(dbw)
+func NewAuthService(c *config.Config) (*AuthService, error) {
+	j, err := newJWTIssuer(c)
 	if err != nil {
-		return a.AuthUser, err
+		return nil, err
+	}
+	return &AuthService{jwt: j}, nil
+}
+
+// IsUserLoggedIn accepts session tokens, personal access tokens, OAuth access
+// tokens and, in JWT mode, JWT access tokens. JWTs are checked without the
+// database. Sessions get full access, the other tokens are limited by their
+// scopes.
+func (a *AuthService) IsUserLoggedIn(dbw types.DatabaseWorker, tokenValue string) (types.AuthUser, error) {
+	var authUser types.AuthUser
+	var userUuid uuid.UUID
+	var scopes []string
+
+	if a.jwt != nil && isJWT(tokenValue) {
+		claims, err := a.jwt.verify(tokenValue, time.Now())
+		if err != nil {
+			return authUser, err
+		}
+		authUser.UserUUID = claims.Subject
+		authUser.IsSuperuser = claims.Superuser
+		authUser.Role = claims.Role
+		return authUser, nil
+	}
+
+	if isOAuthToken(tokenValue) {
+		t := OAuthToken{}
+		err := t.FindAccess(dbw, tokenValue)
+		if err != nil {
+			return authUser, err
+		}
+		userUuid = t.UserUUID
+		scopes = strings.Fields(t.Scopes)
+	} else if isAccessToken(tokenValue) {
+		t := AccessToken{}
+		err := t.Find(dbw, tokenValue)
+		if err != nil {
+			return authUser, err
+		}
+		userUuid = t.UserUUID
+		scopes = []string{t.Scope}
+	} else {
+		s := Session{Token: tokenValue}
+		err := s.Read(dbw)
+		if err != nil {
+			return authUser, err
+		}
+		userUuid = s.UserUuid
 	}
 
-	params := map[string]any{"user_uuid": s.UserUuid}
+	params := map[string]any{"user_uuid": userUuid}
 
-	err = dbw.ReadRecordSubmodel(User{}, a, params)
+	err := dbw.ReadRecordSubmodel(User{}, &authUser, params)
 	if err != nil {
-		return a.AuthUser, err
+		return types.AuthUser{}, err
 	}
-	return a.AuthUser, nil
+	authUser.Scopes = scopes
+	return authUser, nil
 }
@@ -1,14 +1,11 @@
 package user
 
 import (
-	"bytes"
-	"crypto/tls"
 	"fmt"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/gomail.v2"
-	"html/template"
 	"net/http"
 	"time"
+	"todoApp/api/audit"
 	"todoApp/api/service"
 )
 
@@ -45,6 +42,7 @@ func emailFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		before := map[string]any{"emailVerified": usr.EmailVerified}
 		usr.EmailVerified = true
 		err = usr.Update(s.DbWorker)
 		if err != nil {
@@ -54,6 +52,13 @@ func emailFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  usr.UserUUID,
+			EntityKind: audit.KindUser,
+			EntityUUID: usr.UserUUID,
+			Action:     audit.ActionUpdate,
+		}, before, map[string]any{"emailVerified": usr.EmailVerified})
+
 		if time.Since(usr.EmailKeyCreatedAt) >= 24*time.Hour {
 			w.WriteHeader(http.StatusGone)
 			log.WithFields(log.Fields{
@@ -168,42 +173,11 @@ func emailResendFunc(s *Service) http.HandlerFunc {
 }
 
 func sendVerificationEmail(email string, verificationKey string, s *Service) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", s.Config.Config.EmailReply)
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", service.EmailSubject)
-
 	type link struct {
 		Link string
 	}
 
 	l := link{Link: fmt.Sprintf("%s%s", s.Config.Config.DomainName, verificationKey)}
 
-	tmpl, err := template.ParseFiles("static/emailVerification.html")
-	if err != nil {
-		return err
-	}
-
-	var body bytes.Buffer
-	err = tmpl.Execute(&body, l)
-	if err != nil {
-		return err
-	}
-
-	m.SetBody("text/html", body.String())
-
-	d := gomail.NewDialer(
-		s.Config.Config.EmailService,
-		25,
-		s.Config.Config.EmailLogin,
-		s.Config.Config.EmailPass)
-
-	d.TLSConfig = &tls.Config{
-		InsecureSkipVerify: true,
-	}
-
-	if err := d.DialAndSend(m); err != nil {
-		return err
-	}
-	return nil
+	return service.SendEmail(s.Config, email, service.EmailSubject, "static/emailVerification.html", l)
 }
@@ -16,6 +16,9 @@ type Service struct {
 	Salt       []byte
 	Router     *http.ServeMux
 	Config     *config.Config
+	policy     *passwordPolicy
+	jwt        *jwtIssuer
+	oidc       map[string]*oidcProvider
 }
 
 func Init(s *Service) {
@@ -31,5 +34,77 @@ func Init(s *Service) {
 		log.Fatal(service.TableInitErr, err)
 	}
 
+	err = s.DbWorker.InitTable(&UserSettings{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&PasswordReset{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&TwoFactor{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&RecoveryCode{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&LoginChallenge{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&AccessToken{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&OIDCState{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&ExternalIdentity{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&OAuthClient{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&OAuthCode{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&OAuthToken{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	err = s.DbWorker.InitTable(&LoginFailure{})
+	if err != nil {
+		log.Fatal(service.TableInitErr, err)
+	}
+
+	s.policy, err = newPasswordPolicy(s.Config)
+	if err != nil {
+		log.Fatal(service.PasswordPolicyConfigErr, err)
+	}
+
+	s.oidc = newOIDCProviders(s.Config)
+
+	s.jwt, err = newJWTIssuer(s.Config)
+	if err != nil {
+		log.Fatal(service.AuthConfigErr, err)
+	}
+
 	addRoutes(s)
 }
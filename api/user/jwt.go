@@ -0,0 +1,250 @@
+package user
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"todoApp/config"
+)
+
+const (
+	authModeSession = "session"
+	authModeJWT     = "jwt"
+
+	jwtIssuerName     = "todoApp"
+	jwtDefaultTTL     = 15 * time.Minute
+	refreshDefaultTTL = 30 * 24 * time.Hour
+
+	algHS256 = "HS256"
+	algEdDSA = "EdDSA"
+)
+
+var errInvalidJWT = errors.New("invalid access token")
+
+// jwtKey is one signing key. Keys are looked up by kid, so old keys can stay
+// for verification after a new one takes over signing.
+type jwtKey struct {
+	kid     string
+	alg     string
+	secret  []byte
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// jwtIssuer signs and verifies access tokens. The first key signs, all of
+// them verify.
+type jwtIssuer struct {
+	keys       []jwtKey
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// accessClaims carry everything AuthWorker needs, so checking an access token
+// doesn't touch the database. SessionID is the refresh token family.
+type accessClaims struct {
+	Issuer    string    `json:"iss"`
+	Subject   uuid.UUID `json:"sub"`
+	SessionID uuid.UUID `json:"sid"`
+	Superuser bool      `json:"su,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// newJWTIssuer reads the JWT settings from the config. It returns nil when
+// the app runs in the default session mode.
+func newJWTIssuer(c *config.Config) (*jwtIssuer, error) {
+	switch strings.ToLower(strings.TrimSpace(c.Config.AuthMode)) {
+	case "", authModeSession:
+		return nil, nil
+	case authModeJWT:
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", c.Config.AuthMode)
+	}
+
+	keys, err := parseJWTKeys(c.Config.JWTKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jwtIssuer{keys: keys, accessTTL: jwtDefaultTTL, refreshTTL: refreshDefaultTTL}
+	if d, err := time.ParseDuration(c.Config.JWTAccessTTL); err == nil && d > 0 {
+		j.accessTTL = d
+	}
+	if d, err := time.ParseDuration(c.Config.RefreshTokenTTL); err == nil && d > 0 {
+		j.refreshTTL = d
+	}
+	return j, nil
+}
+
+// parseJWTKeys reads "kid:alg:key" entries separated by commas. The key is
+// base64: the secret for HS256 (32 bytes at least) or the 32 byte seed for
+// EdDSA.
+func parseJWTKeys(value string) ([]jwtKey, error) {
+	var keys []jwtKey
+	seen := map[string]bool{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("JWT key %q is not kid:alg:key", entry)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("JWT key id %q is used twice", parts[0])
+		}
+		seen[parts[0]] = true
+
+		raw, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", parts[0], err)
+		}
+
+		k := jwtKey{kid: parts[0], alg: parts[1]}
+		switch k.alg {
+		case algHS256:
+			if len(raw) < 32 {
+				return nil, fmt.Errorf("JWT key %q: HS256 secret must be at least 32 bytes", k.kid)
+			}
+			k.secret = raw
+		case algEdDSA:
+			if len(raw) != ed25519.SeedSize {
+				return nil, fmt.Errorf("JWT key %q: EdDSA seed must be %d bytes", k.kid, ed25519.SeedSize)
+			}
+			k.private = ed25519.NewKeyFromSeed(raw)
+			k.public = k.private.Public().(ed25519.PublicKey)
+		default:
+			return nil, fmt.Errorf("JWT key %q: unsupported algorithm %q", k.kid, k.alg)
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWT mode needs at least one key in JWT_KEYS")
+	}
+	return keys, nil
+}
+
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// issue signs an access token for the user and returns it with its expiry.
+func (j *jwtIssuer) issue(usr User, family uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(j.accessTTL)
+
+	token, err := j.sign(accessClaims{
+		Issuer:    jwtIssuerName,
+		Subject:   usr.UserUUID,
+		SessionID: family,
+		Superuser: usr.IsSuperuser,
+		Role:      usr.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	})
+	return token, expires, err
+}
+
+func (j *jwtIssuer) sign(claims accessClaims) (string, error) {
+	k := j.keys[0]
+
+	header, err := json.Marshal(jwtHeader{Alg: k.alg, Typ: "JWT", Kid: k.kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	return signingInput + "." + enc.EncodeToString(k.signature([]byte(signingInput))), nil
+}
+
+// verify checks the signature with the key named by kid and the expiry. The
+// algorithm comes from the key, never from the token.
+func (j *jwtIssuer) verify(token string, now time.Time) (accessClaims, error) {
+	var claims accessClaims
+	enc := base64.RawURLEncoding
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errInvalidJWT
+	}
+
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return claims, errInvalidJWT
+	}
+	var header jwtHeader
+	if err = json.Unmarshal(rawHeader, &header); err != nil {
+		return claims, errInvalidJWT
+	}
+
+	k, ok := j.key(header.Kid)
+	if !ok || header.Alg != k.alg {
+		return claims, errInvalidJWT
+	}
+
+	signature, err := enc.DecodeString(parts[2])
+	if err != nil || !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return claims, errInvalidJWT
+	}
+
+	rawPayload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return claims, errInvalidJWT
+	}
+	if err = json.Unmarshal(rawPayload, &claims); err != nil {
+		return claims, errInvalidJWT
+	}
+
+	if claims.Issuer != jwtIssuerName || claims.Subject == uuid.Nil || now.Unix() >= claims.ExpiresAt {
+		return claims, errInvalidJWT
+	}
+	return claims, nil
+}
+
+func (j *jwtIssuer) key(kid string) (jwtKey, bool) {
+	for _, k := range j.keys {
+		if k.kid == kid {
+			return k, true
+		}
+	}
+	return jwtKey{}, false
+}
+
+func (k jwtKey) signature(input []byte) []byte {
+	if k.alg == algEdDSA {
+		return ed25519.Sign(k.private, input)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+func (k jwtKey) verify(input, signature []byte) bool {
+	if k.alg == algEdDSA {
+		return ed25519.Verify(k.public, input, signature)
+	}
+	return hmac.Equal(k.signature(input), signature)
+}
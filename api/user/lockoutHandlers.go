@@ -0,0 +1,75 @@
+package user
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"todoApp/api/service"
+)
+
+// sendUnlockLink mails the owner of a throttled account a link that lifts
+// the lock.
+func sendUnlockLink(s *Service, usr User, f *LoginFailure) error {
+	key, err := f.SetUnlockKey(s.DbWorker)
+	if err != nil {
+		return err
+	}
+
+	type link struct {
+		Link string
+	}
+	l := link{Link: fmt.Sprintf("%s%s", s.Config.Config.UnlockURL, key)}
+
+	err = service.SendEmail(s.Config, usr.Email, service.UnlockSubject, "static/accountUnlock.html", l)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"id": usr.UserUUID,
+	}).Info(service.UnlockSent)
+	return nil
+}
+
+// unlockAccountFunc     godoc
+//
+//	@Summary		Unlock account
+//	@Description	Lifts the login lock of an account with the key from the unlock email. The key works once.
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			key	path		string					true	"unlock key"
+//	@Success		200	{object}	service.DefaultResponse	"OK"
+//	@Failure		400	{object}	service.errorResponse	"Bad request"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/login/unlock/{key} [post]
+func unlockAccountFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		f := LoginFailure{}
+		err := f.Unlock(s.DbWorker, r.PathValue("key"))
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusBadRequest)
+				log.Error(service.UnlockKeyErr)
+				service.BadRequestResponse(w, service.UnlockKeyErr, nil)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UnlockErr, err)
+			service.InternalServerErrorResponse(w, service.UnlockErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"account": f.Key,
+		}).Info(service.UnlockSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.UnlockSuccess,
+			Data:       nil,
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+// throttle is the backoff of one kind of key: the first free failures cost
+// nothing, then every failure doubles the wait up to max. Failures older
+// than window are forgotten.
+type throttle struct {
+	free   int
+	max    time.Duration
+	window time.Duration
+}
+
+var (
+	accountThrottle = throttle{free: 3, max: time.Hour, window: 24 * time.Hour}
+	ipThrottle      = throttle{free: 10, max: time.Hour, window: time.Hour}
+	// resetThrottle limits password reset requests per IP.
+	resetThrottle = throttle{free: 5, max: time.Hour, window: time.Hour}
+)
+
+// accountLockoutAt is the number of failures after which the owner gets an
+// email with an unlock link.
+const accountLockoutAt = 10
+
+// LoginFailure counts failed logins of an email or an IP address. Keys of
+// unknown emails are tracked too, so they behave exactly like real ones.
+type LoginFailure struct {
+	ID           uint   `gorm:"primarykey"`
+	Key          string `gorm:"uniqueIndex"`
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  time.Time
+	UnlockHash   string `gorm:"index"`
+}
+
+type failureUpdate struct {
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  time.Time
+	UnlockHash   string
+}
+
+type unlockKeyUpdate struct {
+	UnlockHash string
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipKey identifies the client by its address, see service.ClientIP.
+func ipKey(r *http.Request, header string) string {
+	return "ip:" + service.ClientIP(r, header)
+}
+
+// delay is how long to wait after the given number of failures.
+func (t throttle) delay(failures int) time.Duration {
+	if failures <= t.free {
+		return 0
+	}
+	shift := failures - t.free - 1
+	if shift >= 32 {
+		return t.max
+	}
+	return min(time.Second<<shift, t.max)
+}
+
+// RetryAfter tells how long the key stays locked, zero if it is not.
+func (f *LoginFailure) RetryAfter(now time.Time) time.Duration {
+	if now.Before(f.LockedUntil) {
+		return f.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// Claim reserves a login attempt before the credentials are checked. The
+// row stays locked while it is decided, so parallel attempts queue up here
+// and each of them sees the backoff of those before it. A locked key is not
+// counted again, the wait is returned instead. The attempt counts as a
+// failure until Release or Reset takes it back.
+func (f *LoginFailure) Claim(wrk dbWorker, t throttle, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	err := wrk.Transaction(func(tx types.DatabaseWorker) error {
+		err := f.lock(tx)
+		if err != nil {
+			return err
+		}
+
+		wait = f.RetryAfter(now)
+		if wait > 0 {
+			return nil
+		}
+
+		if now.Sub(f.LastFailedAt) > t.window {
+			f.Failures = 0
+		}
+		f.Failures++
+		f.LastFailedAt = now
+		f.LockedUntil = now.Add(t.delay(f.Failures))
+		return f.save(tx)
+	})
+	return wait, err
+}
+
+// Release takes back an attempt reserved by Claim that turned out to be
+// right, and shortens the backoff to what the remaining failures cost.
+func (f *LoginFailure) Release(wrk dbWorker, t throttle) error {
+	return wrk.Transaction(func(tx types.DatabaseWorker) error {
+		err := f.lock(tx)
+		if err != nil {
+			return err
+		}
+		if f.Failures == 0 {
+			return nil
+		}
+
+		f.Failures--
+		if until := f.LastFailedAt.Add(t.delay(f.Failures)); until.Before(f.LockedUntil) {
+			f.LockedUntil = until
+		}
+		return f.save(tx)
+	})
+}
+
+// lock loads the counter of f.Key and locks its row until the transaction
+// ends, creating it first if the key is new.
+func (f *LoginFailure) lock(tx types.DatabaseWorker) error {
+	err := tx.CreateIfMissing(&LoginFailure{Key: f.Key})
+	if err != nil && err.Error() != "409" {
+		return err
+	}
+	return tx.ReadOneRecord(f, map[string]any{"key": f.Key, "lock": true})
+}
+
+// SetUnlockKey stores a new unlock key and returns its plain value.
+func (f *LoginFailure) SetUnlockKey(wrk dbWorker) (string, error) {
+	key, err := generateEmailVerificationKey()
+	if err != nil {
+		return "", err
+	}
+	f.UnlockHash = hashKey(key)
+	update := unlockKeyUpdate{UnlockHash: f.UnlockHash}
+	return key, wrk.UpdateRecordSubmodel(LoginFailure{}, &update, map[string]any{"key": f.Key})
+}
+
+// Reset forgets all failures of f.Key.
+func (f *LoginFailure) Reset(wrk dbWorker) error {
+	f.Failures = 0
+	f.LastFailedAt = time.Time{}
+	f.LockedUntil = time.Time{}
+	f.UnlockHash = ""
+	err := wrk.UpdateRecordSubmodel(LoginFailure{}, &failureUpdate{}, map[string]any{"key": f.Key})
+	if err != nil && err.Error() == "404" {
+		return nil
+	}
+	return err
+}
+
+// Unlock resets the account behind an unlock key.
+func (f *LoginFailure) Unlock(wrk dbWorker, key string) error {
+	err := wrk.ReadOneRecord(f, map[string]any{"unlock_hash": hashKey(key)})
+	if err != nil {
+		return err
+	}
+	return f.Reset(wrk)
+}
+
+func (f *LoginFailure) save(wrk dbWorker) error {
+	update := failureUpdate{
+		Failures:     f.Failures,
+		LastFailedAt: f.LastFailedAt,
+		LockedUntil:  f.LockedUntil,
+		UnlockHash:   f.UnlockHash,
+	}
+	return wrk.UpdateRecordSubmodel(LoginFailure{}, &update, map[string]any{"id": f.ID})
+}
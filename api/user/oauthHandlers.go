@@ -0,0 +1,619 @@
+package user
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// createOAuthClientFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Register OAuth client
+//	@Description	Registers a third-party app that can ask users for access to their lists and tasks. Redirect URIs must be https (http is allowed for localhost). The secret of confidential clients is shown only once, public clients have none and must use PKCE.
+//	@Tags			OAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		createOAuthClient		true	"Client"
+//	@Success		201		{object}	createdOAuthClient		"Created"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/oauth/clients [post]
+func createOAuthClientFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		c := createOAuthClient{}
+		err = service.DeserializeJSON(data, &c)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = c.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		client := OAuthClient{OwnerUUID: session.UserUuid}
+		secret, err := client.Create(s.DbWorker, c)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OAuthClientCreateErr, err)
+			service.InternalServerErrorResponse(w, service.OAuthClientCreateErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		log.WithFields(log.Fields{
+			"clientId": client.ClientID,
+			"owner":    session.UserUuid,
+		}).Info(service.OAuthClientCreateSuccess)
+		service.OkResponse(w, createdOAuthClient{OAuthClient: client, ClientSecret: secret})
+	}
+}
+
+// getOAuthClientsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get OAuth clients
+//	@Description	Requests OAuth clients registered by the user
+//	@Tags			OAuth
+//	@Produce		json
+//	@Success		200	{array}		OAuthClient				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/oauth/clients [get]
+func getOAuthClientsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		client := OAuthClient{OwnerUUID: session.UserUuid}
+		clients, err := client.ReadAll(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OAuthClientReadErr, err)
+			service.InternalServerErrorResponse(w, service.OAuthClientReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.OAuthClientReadSuccess)
+		service.OkResponse(w, clients)
+	}
+}
+
+// deleteOAuthClientFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Delete OAuth client
+//	@Description	Deletes the user's OAuth client, all tokens issued to it stop working
+//	@Tags			OAuth
+//	@Produce		json
+//	@Param			clientId	path		string					true	"client id"
+//	@Success		200			{object}	service.DefaultResponse	"OK"
+//	@Failure		401			{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404			{object}	service.errorResponse	"Not Found"
+//	@Failure		500			{object}	service.errorResponse	"Internal server error"
+//	@Router			/oauth/clients/{clientId} [delete]
+func deleteOAuthClientFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		client := OAuthClient{ClientID: r.PathValue("clientId"), OwnerUUID: session.UserUuid}
+		err = client.Delete(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OAuthClientDeleteErr, err)
+			service.InternalServerErrorResponse(w, service.OAuthClientDeleteErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"clientId": client.ClientID,
+		}).Info(service.OAuthClientDeleteSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.OAuthClientDeleteSuccess,
+			Data:       nil,
+		})
+	}
+}
+
+// checkAuthorizeRequest validates an authorization request and returns the
+// client with the requested scopes.
+func checkAuthorizeRequest(s *Service, req authorizeRequest) (OAuthClient, []string, error) {
+	client := OAuthClient{ClientID: req.ClientID}
+	err := client.Read(s.DbWorker)
+	if err != nil {
+		if err.Error() == "404" {
+			return client, nil, errors.New("unknown client")
+		}
+		return client, nil, err
+	}
+	if !client.HasRedirect(req.RedirectURI) {
+		return client, nil, errors.New("redirect URI is not registered")
+	}
+	if req.ResponseType != "code" {
+		return client, nil, errors.New("response type must be code")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return client, nil, errors.New("PKCE with S256 is required")
+	}
+
+	scopes, err := parseScopes(req.Scope)
+	if err != nil {
+		return client, nil, err
+	}
+	return client, scopes, nil
+}
+
+// authorizeFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		OAuth authorization request
+//	@Description	Checks a client's authorization request for the logged in user and returns what the consent screen should show. Scopes: lists:read, lists:write, tasks:read, tasks:write, none means all. Export and import need a full access login.
+//	@Tags			OAuth
+//	@Produce		json
+//	@Param			response_type			query		string					true	"code"
+//	@Param			client_id				query		string					true	"client id"
+//	@Param			redirect_uri			query		string					true	"registered redirect URI"
+//	@Param			scope					query		string					false	"space separated scopes"
+//	@Param			state					query		string					false	"state"
+//	@Param			code_challenge			query		string					true	"PKCE challenge"
+//	@Param			code_challenge_method	query		string					true	"S256"
+//	@Success		200						{object}	consentResponse			"OK"
+//	@Failure		400						{object}	service.errorResponse	"Bad request"
+//	@Failure		401						{object}	service.errorResponse	"Unauthorized"
+//	@Router			/oauth/authorize [get]
+func authorizeFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		_, err = readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		q := r.URL.Query()
+		req := authorizeRequest{
+			ResponseType:        q.Get("response_type"),
+			ClientID:            q.Get("client_id"),
+			RedirectURI:         q.Get("redirect_uri"),
+			Scope:               q.Get("scope"),
+			State:               q.Get("state"),
+			CodeChallenge:       q.Get("code_challenge"),
+			CodeChallengeMethod: q.Get("code_challenge_method"),
+		}
+
+		client, scopes, err := checkAuthorizeRequest(s, req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.OAuthRequestErr, err)
+			service.BadRequestResponse(w, service.OAuthRequestErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		service.OkResponse(w, consentResponse{
+			Client:      client.Name,
+			ClientID:    client.ClientID,
+			Scopes:      scopes,
+			RedirectURI: req.RedirectURI,
+		})
+	}
+}
+
+// consentFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		OAuth consent
+//	@Description	Posts the user's decision on an authorization request. Returns where to send the browser: the client's redirect URI with a code or with access_denied.
+//	@Tags			OAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		authorizeRequest		true	"Authorization request and decision"
+//	@Success		200		{object}	redirectResponse		"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		415		{object}	service.errorResponse	"Unsupported media type"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/oauth/authorize [post]
+func consentFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		// application/json only: browsers can't send it cross-site without a
+		// CORS preflight, while a form can post a JSON-shaped text/plain body
+		// with the session cookie. Other sites can't approve on the user's
+		// behalf this way.
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			log.Error(service.OAuthContentTypeErr)
+			service.UnsupportedMediaTypeResponse(w, service.OAuthContentTypeErr)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		req := authorizeRequest{}
+		err = service.DeserializeJSON(data, &req)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		client, scopes, err := checkAuthorizeRequest(s, req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.OAuthRequestErr, err)
+			service.BadRequestResponse(w, service.OAuthRequestErr, err)
+			return
+		}
+
+		redirect, _ := url.Parse(req.RedirectURI)
+		q := redirect.Query()
+		if req.State != "" {
+			q.Set("state", req.State)
+		}
+
+		if !req.Approve {
+			q.Set("error", "access_denied")
+			redirect.RawQuery = q.Encode()
+
+			w.WriteHeader(http.StatusOK)
+			log.WithFields(log.Fields{
+				"clientId": client.ClientID,
+				"id":       session.UserUuid,
+			}).Info(service.OAuthConsentDenied)
+			service.OkResponse(w, redirectResponse{RedirectTo: redirect.String()})
+			return
+		}
+
+		oc := OAuthCode{
+			ClientID:    client.ClientID,
+			UserUUID:    session.UserUuid,
+			RedirectURI: req.RedirectURI,
+			Scopes:      strings.Join(scopes, " "),
+			Challenge:   req.CodeChallenge,
+		}
+		code, err := oc.Create(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OAuthConsentErr, err)
+			service.InternalServerErrorResponse(w, service.OAuthConsentErr, err)
+			return
+		}
+
+		q.Set("code", code)
+		redirect.RawQuery = q.Encode()
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindSession,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionCreate,
+		}, nil, map[string]any{"oauthClient": client.ClientID, "scopes": scopes})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"clientId": client.ClientID,
+			"id":       session.UserUuid,
+		}).Info(service.OAuthConsentGranted)
+		service.OkResponse(w, redirectResponse{RedirectTo: redirect.String()})
+	}
+}
+
+// oauthJSON writes responses of the token and introspection endpoints in
+// the plain RFC format instead of the usual envelope.
+func oauthJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Error(service.ServerResponseErr, err)
+	}
+}
+
+// oauthClientAuth authenticates the calling client with HTTP Basic or with
+// client_id and client_secret in the form.
+func oauthClientAuth(s *Service, r *http.Request) (OAuthClient, bool) {
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client := OAuthClient{ClientID: id}
+	if id == "" || client.Read(s.DbWorker) != nil || !client.Authenticate(secret) {
+		return client, false
+	}
+	return client, true
+}
+
+// oauthTokenFunc godoc
+//
+//	@Summary		OAuth token endpoint
+//	@Description	Form encoded, RFC 6749. grant_type authorization_code needs code, redirect_uri and code_verifier, grant_type refresh_token needs refresh_token and takes an optional narrower scope. Confidential clients authenticate with HTTP Basic or client_id and client_secret.
+//	@Tags			OAuth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			grant_type		formData	string				true	"authorization_code or refresh_token"
+//	@Param			code			formData	string				false	"authorization code"
+//	@Param			redirect_uri	formData	string				false	"redirect URI of the authorization request"
+//	@Param			code_verifier	formData	string				false	"PKCE verifier"
+//	@Param			refresh_token	formData	string				false	"refresh token"
+//	@Param			scope			formData	string				false	"scopes"
+//	@Param			client_id		formData	string				false	"client id"
+//	@Param			client_secret	formData	string				false	"client secret"
+//	@Success		200				{object}	oauthTokenResponse	"OK"
+//	@Failure		400				{object}	oauthError			"Bad request"
+//	@Failure		401				{object}	oauthError			"Unauthorized"
+//	@Router			/oauth/token [post]
+func oauthTokenFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request"})
+			return
+		}
+
+		client, ok := oauthClientAuth(s, r)
+		if !ok {
+			log.Error(service.OAuthClientAuthErr)
+			oauthJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_client"})
+			return
+		}
+
+		var access, refresh string
+		t := OAuthToken{}
+
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			oc := OAuthCode{}
+			err = oc.Redeem(s.DbWorker, r.PostForm.Get("code"), client.ClientID)
+			if err != nil {
+				if err.Error() == "404" {
+					oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant"})
+					return
+				}
+				log.Error(service.OAuthTokenErr, err)
+				oauthJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+				return
+			}
+
+			verifier := r.PostForm.Get("code_verifier")
+			if oc.RedirectURI != r.PostForm.Get("redirect_uri") ||
+				len(verifier) < 43 || len(verifier) > 128 ||
+				subtle.ConstantTimeCompare([]byte(pkceChallenge(verifier)), []byte(oc.Challenge)) != 1 {
+				oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant"})
+				return
+			}
+
+			t = OAuthToken{ClientID: client.ClientID, UserUUID: oc.UserUUID, Scopes: oc.Scopes}
+			access, refresh, err = t.Issue(s.DbWorker)
+
+		case "refresh_token":
+			var scopes []string
+			if r.PostForm.Get("scope") != "" {
+				scopes, err = parseScopes(r.PostForm.Get("scope"))
+				if err != nil {
+					oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_scope", Description: err.Error()})
+					return
+				}
+			}
+
+			access, refresh, err = t.Refresh(s.DbWorker, r.PostForm.Get("refresh_token"), client.ClientID, scopes)
+			if errors.Is(err, errOAuthScope) {
+				oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_scope", Description: err.Error()})
+				return
+			}
+			if err != nil && err.Error() == "404" {
+				oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant"})
+				return
+			}
+
+		default:
+			oauthJSON(w, http.StatusBadRequest, oauthError{Error: "unsupported_grant_type"})
+			return
+		}
+
+		if err != nil {
+			log.Error(service.OAuthTokenErr, err)
+			oauthJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"clientId": client.ClientID,
+			"id":       t.UserUUID,
+		}).Info(service.OAuthTokenSuccess)
+		oauthJSON(w, http.StatusOK, oauthTokenResponse{
+			AccessToken:  access,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(oauthAccessTTL.Seconds()),
+			RefreshToken: refresh,
+			Scope:        t.Scopes,
+		})
+	}
+}
+
+// oauthIntrospectFunc godoc
+//
+//	@Summary		OAuth token introspection
+//	@Description	Form encoded, RFC 7662. Clients can introspect only tokens issued to them, other tokens are reported inactive.
+//	@Tags			OAuth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token			formData	string			true	"access or refresh token"
+//	@Param			client_id		formData	string			false	"client id"
+//	@Param			client_secret	formData	string			false	"client secret"
+//	@Success		200				{object}	introspection	"OK"
+//	@Failure		401				{object}	oauthError		"Unauthorized"
+//	@Router			/oauth/introspect [post]
+func oauthIntrospectFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			oauthJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request"})
+			return
+		}
+
+		client, ok := oauthClientAuth(s, r)
+		if !ok {
+			log.Error(service.OAuthClientAuthErr)
+			oauthJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_client"})
+			return
+		}
+
+		t := OAuthToken{}
+		token := r.PostForm.Get("token")
+		err = t.Find(s.DbWorker, token)
+		if err != nil || t.ClientID != client.ClientID || t.RevokedAt != nil {
+			oauthJSON(w, http.StatusOK, introspection{Active: false})
+			return
+		}
+
+		expires := t.AccessExpiresAt
+		tokenType := "access_token"
+		if strings.HasPrefix(token, oauthRefreshPrefix) {
+			expires = t.RefreshExpiresAt
+			tokenType = "refresh_token"
+		}
+		if time.Now().After(expires) {
+			oauthJSON(w, http.StatusOK, introspection{Active: false})
+			return
+		}
+
+		oauthJSON(w, http.StatusOK, introspection{
+			Active:    true,
+			Scope:     t.Scopes,
+			ClientID:  t.ClientID,
+			Subject:   t.UserUUID.String(),
+			TokenType: tokenType,
+			ExpiresAt: expires.Unix(),
+			IssuedAt:  t.CreatedAt.Unix(),
+		})
+	}
+}
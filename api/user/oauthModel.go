@@ -0,0 +1,422 @@
+package user
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"todoApp/types"
+)
+
+const (
+	oauthCodeTTL    = 5 * time.Minute
+	oauthAccessTTL  = time.Hour
+	oauthRefreshTTL = 30 * 24 * time.Hour
+
+	oauthAccessPrefix  = "tdo_"
+	oauthRefreshPrefix = "tdr_"
+)
+
+var errOAuthScope = errors.New("scope was not granted")
+
+// oauthScopes are all scopes a client may ask for.
+var oauthScopes = []string{
+	types.ResourceLists + ":" + types.ScopeRead,
+	types.ResourceLists + ":" + types.ScopeWrite,
+	types.ResourceTasks + ":" + types.ScopeRead,
+	types.ResourceTasks + ":" + types.ScopeWrite,
+}
+
+// OAuthClient is a third-party app registered by a user. Public clients
+// (e.g. mobile apps) have no secret and rely on PKCE alone.
+type OAuthClient struct {
+	ID           uint      `gorm:"primarykey" json:"-"`
+	ClientID     string    `gorm:"uniqueIndex" json:"clientId" extensions:"x-order=1"`
+	SecretHash   string    `json:"-"`
+	Name         string    `json:"name" extensions:"x-order=2"`
+	RedirectURIs string    `json:"-"`
+	Redirects    []string  `gorm:"-" json:"redirectUris" extensions:"x-order=3"`
+	Public       bool      `json:"public" extensions:"x-order=4"`
+	OwnerUUID    uuid.UUID `gorm:"index" json:"-"`
+	CreatedAt    time.Time `json:"created" extensions:"x-order=5"`
+}
+
+// OAuthCode is a single-use authorization code bound to the PKCE challenge.
+type OAuthCode struct {
+	ID          uint   `gorm:"primarykey"`
+	CodeHash    string `gorm:"uniqueIndex"`
+	ClientID    string
+	UserUUID    uuid.UUID
+	RedirectURI string
+	Scopes      string
+	Challenge   string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	CreatedAt   time.Time
+}
+
+// OAuthToken is an access and refresh token pair issued to a client. Only
+// hashes are stored. Refreshing revokes the pair and issues a new one.
+type OAuthToken struct {
+	ID               uint   `gorm:"primarykey"`
+	AccessHash       string `gorm:"uniqueIndex"`
+	RefreshHash      string `gorm:"uniqueIndex"`
+	ClientID         string `gorm:"index"`
+	UserUUID         uuid.UUID
+	Scopes           string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+	RevokedAt        *time.Time
+	CreatedAt        time.Time
+}
+
+type createOAuthClient struct {
+	Name         string   `json:"name" example:"My integration" extensions:"x-order=1"`
+	RedirectURIs []string `json:"redirectUris" example:"https://app.example.com/callback" extensions:"x-order=2"`
+	Public       bool     `json:"public" extensions:"x-order=3"`
+}
+
+type createdOAuthClient struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret,omitempty" extensions:"x-order=0"`
+}
+
+// authorizeRequest is the query of /oauth/authorize, the consent decision
+// is posted back with the same fields and approve.
+type authorizeRequest struct {
+	ResponseType        string `json:"responseType"`
+	ClientID            string `json:"clientId"`
+	RedirectURI         string `json:"redirectUri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+	Approve             bool   `json:"approve"`
+}
+
+type consentResponse struct {
+	Client      string   `json:"client" extensions:"x-order=1"`
+	ClientID    string   `json:"clientId" extensions:"x-order=2"`
+	Scopes      []string `json:"scopes" extensions:"x-order=3"`
+	RedirectURI string   `json:"redirectUri" extensions:"x-order=4"`
+}
+
+type redirectResponse struct {
+	RedirectTo string `json:"redirectTo" extensions:"x-order=1"`
+}
+
+// oauthTokenResponse and oauthError follow RFC 6749, clients expect exactly
+// these fields.
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// introspection follows RFC 7662.
+type introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+type oauthCodeUsed struct {
+	UsedAt *time.Time
+}
+
+type oauthTokenRevoked struct {
+	RevokedAt *time.Time
+}
+
+func (c *createOAuthClient) validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+	if len(c.RedirectURIs) == 0 {
+		return errors.New("at least one redirect URI is required")
+	}
+	for _, uri := range c.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || u.Fragment != "" || u.Host == "" || strings.ContainsAny(uri, " \n") {
+			return fmt.Errorf("redirect URI %q is not valid", uri)
+		}
+		if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+			return fmt.Errorf("redirect URI %q must use https", uri)
+		}
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// parseScopes checks a space separated scope string, an empty one means all
+// scopes.
+func parseScopes(scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return slices.Clone(oauthScopes), nil
+	}
+	for _, s := range scopes {
+		if !slices.Contains(oauthScopes, s) {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// Create registers the client and returns its secret, empty for public
+// clients.
+func (c *OAuthClient) Create(wrk dbWorker, data createOAuthClient) (string, error) {
+	id, err := generateEmailVerificationKey()
+	if err != nil {
+		return "", err
+	}
+
+	secret := ""
+	if !data.Public {
+		secret, err = generateToken(32, nil)
+		if err != nil {
+			return "", err
+		}
+		c.SecretHash = hashKey(secret)
+	}
+
+	c.ClientID = id
+	c.Name = data.Name
+	c.Redirects = data.RedirectURIs
+	c.RedirectURIs = strings.Join(data.RedirectURIs, " ")
+	c.Public = data.Public
+
+	err = wrk.CreateRecord(c)
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (c *OAuthClient) Read(wrk dbWorker) error {
+	err := wrk.ReadOneRecord(c, map[string]any{"client_id": c.ClientID})
+	if err != nil {
+		return err
+	}
+	c.Redirects = strings.Fields(c.RedirectURIs)
+	return nil
+}
+
+func (c *OAuthClient) ReadAll(wrk dbWorker) ([]OAuthClient, error) {
+	clients := []OAuthClient{}
+	params := map[string]any{"owner_uuid": c.OwnerUUID, "order": "desc", "sort_by": "created_at"}
+	err := wrk.ReadManyRecords(OAuthClient{}, &clients, params)
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+	for i := range clients {
+		clients[i].Redirects = strings.Fields(clients[i].RedirectURIs)
+	}
+	return clients, nil
+}
+
+// Delete removes the owner's client together with its codes and tokens.
+func (c *OAuthClient) Delete(wrk dbWorker) error {
+	params := map[string]any{"client_id": c.ClientID, "owner_uuid": c.OwnerUUID}
+	err := wrk.ReadOneRecord(c, params)
+	if err != nil {
+		return err
+	}
+
+	return wrk.Transaction(func(tx types.DatabaseWorker) error {
+		for _, model := range []any{&OAuthCode{}, &OAuthToken{}} {
+			err := tx.DeleteRecord(model, map[string]any{"client_id": c.ClientID})
+			if err != nil && err.Error() != "404" {
+				return err
+			}
+		}
+		return tx.DeleteRecord(&OAuthClient{}, map[string]any{"id": c.ID})
+	})
+}
+
+// Authenticate checks the secret of a confidential client. Public clients
+// have none to check.
+func (c *OAuthClient) Authenticate(secret string) bool {
+	if c.Public {
+		return secret == ""
+	}
+	return subtle.ConstantTimeCompare([]byte(hashKey(secret)), []byte(c.SecretHash)) == 1
+}
+
+// HasRedirect requires an exact match with a registered URI.
+func (c *OAuthClient) HasRedirect(uri string) bool {
+	return slices.Contains(c.Redirects, uri)
+}
+
+// Create stores the code and returns its plain value.
+func (o *OAuthCode) Create(wrk dbWorker) (string, error) {
+	code, err := generateToken(32, nil)
+	if err != nil {
+		return "", err
+	}
+
+	o.CodeHash = hashKey(code)
+	o.ExpiresAt = time.Now().Add(oauthCodeTTL)
+
+	err = wrk.CreateRecord(o)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Redeem burns the code of the client. Unknown, used and expired codes all
+// return a 404.
+func (o *OAuthCode) Redeem(wrk dbWorker, code, clientID string) error {
+	params := map[string]any{"code_hash": hashKey(code), "client_id": clientID, "used_at IS": nil}
+	err := wrk.ReadOneRecord(o, params)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(o.ExpiresAt) {
+		return errors.New("404")
+	}
+
+	now := time.Now()
+	err = wrk.UpdateRecordSubmodel(OAuthCode{}, &oauthCodeUsed{UsedAt: &now}, map[string]any{"id": o.ID, "used_at IS": nil})
+	if err != nil {
+		return err
+	}
+	o.UsedAt = &now
+	return nil
+}
+
+// Find loads the token pair of an access or refresh token, whatever its
+// state.
+func (t *OAuthToken) Find(wrk dbWorker, token string) error {
+	column := "access_hash"
+	if strings.HasPrefix(token, oauthRefreshPrefix) {
+		column = "refresh_hash"
+	}
+	return wrk.ReadOneRecord(t, map[string]any{column: hashKey(token)})
+}
+
+// Issue creates a new token pair and returns the plain values.
+func (t *OAuthToken) Issue(wrk dbWorker) (string, string, error) {
+	access, err := generateToken(32, nil)
+	if err != nil {
+		return "", "", err
+	}
+	refresh, err := generateToken(32, nil)
+	if err != nil {
+		return "", "", err
+	}
+	access = oauthAccessPrefix + access
+	refresh = oauthRefreshPrefix + refresh
+
+	now := time.Now()
+	t.AccessHash = hashKey(access)
+	t.RefreshHash = hashKey(refresh)
+	t.AccessExpiresAt = now.Add(oauthAccessTTL)
+	t.RefreshExpiresAt = now.Add(oauthRefreshTTL)
+
+	err = wrk.CreateRecord(t)
+	if err != nil {
+		return "", "", err
+	}
+	return access, refresh, nil
+}
+
+// FindAccess loads the live token pair of an access token.
+func (t *OAuthToken) FindAccess(wrk dbWorker, access string) error {
+	params := map[string]any{"access_hash": hashKey(access), "revoked_at IS": nil}
+	err := wrk.ReadOneRecord(t, params)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(t.AccessExpiresAt) {
+		return errors.New("404")
+	}
+	return nil
+}
+
+// Refresh revokes the pair of the refresh token and issues a new one with
+// the same or fewer scopes.
+func (t *OAuthToken) Refresh(wrk dbWorker, refresh, clientID string, scopes []string) (string, string, error) {
+	old := OAuthToken{}
+	params := map[string]any{"refresh_hash": hashKey(refresh), "client_id": clientID, "revoked_at IS": nil}
+	err := wrk.ReadOneRecord(&old, params)
+	if err != nil {
+		return "", "", err
+	}
+	if time.Now().After(old.RefreshExpiresAt) {
+		return "", "", errors.New("404")
+	}
+
+	granted := strings.Fields(old.Scopes)
+	if scopes == nil {
+		scopes = granted
+	}
+	for _, s := range scopes {
+		if !slices.Contains(granted, s) {
+			return "", "", errOAuthScope
+		}
+	}
+
+	var access string
+	err = wrk.Transaction(func(tx types.DatabaseWorker) error {
+		now := time.Now()
+		params := map[string]any{"id": old.ID, "revoked_at IS": nil}
+		err := tx.UpdateRecordSubmodel(OAuthToken{}, &oauthTokenRevoked{RevokedAt: &now}, params)
+		if err != nil {
+			return err
+		}
+
+		t.ClientID = old.ClientID
+		t.UserUUID = old.UserUUID
+		t.Scopes = strings.Join(scopes, " ")
+		access, refresh, err = t.Issue(tx)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return access, refresh, nil
+}
+
+func isOAuthToken(token string) bool {
+	return strings.HasPrefix(token, oauthAccessPrefix)
+}
+
+// RevokeAll revokes every token pair of t.UserUUID and burns the codes that
+// weren't redeemed yet.
+func (t *OAuthToken) RevokeAll(wrk dbWorker) error {
+	now := time.Now()
+	params := map[string]any{"user_uuid": t.UserUUID, "revoked_at IS": nil}
+	err := wrk.UpdateRecordSubmodel(OAuthToken{}, &oauthTokenRevoked{RevokedAt: &now}, params)
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+
+	params = map[string]any{"user_uuid": t.UserUUID, "used_at IS": nil}
+	err = wrk.UpdateRecordSubmodel(OAuthCode{}, &oauthCodeUsed{UsedAt: &now}, params)
+	if err != nil && err.Error() == "404" {
+		return nil
+	}
+	return err
+}
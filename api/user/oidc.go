@@ -0,0 +1,440 @@
+package user
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"todoApp/config"
+)
+
+const (
+	oidcDiscoveryTTL = time.Hour
+	oidcJWKSTTL      = time.Hour
+	// oidcJWKSRefetch limits refetching the keys when a token names an
+	// unknown kid, so garbage tokens can't hammer the provider.
+	oidcJWKSRefetch = time.Minute
+	oidcClockSkew   = time.Minute
+)
+
+var errInvalidIDToken = errors.New("invalid ID token")
+
+// oidcProvider talks to one external OpenID Connect provider. Discovery
+// document and signing keys are cached.
+type oidcProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+
+	mu            sync.Mutex
+	discovery     oidcDiscovery
+	discoveredAt  time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+	keysAttemptAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// idTokenClaims are the ID token claims the login needs. Audience may be a
+// string or a list, so it is decoded separately.
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      json.RawMessage `json:"aud"`
+	AuthorizedBy  string          `json:"azp"`
+	ExpiresAt     int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	GivenName     string          `json:"given_name"`
+	FamilyName    string          `json:"family_name"`
+	Username      string          `json:"preferred_username"`
+}
+
+func newOIDCProviders(c *config.Config) map[string]*oidcProvider {
+	providers := map[string]*oidcProvider{}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, p := range c.Config.OIDCProviders {
+		if p.Issuer == "" || p.ClientID == "" {
+			continue
+		}
+		providers[p.Name] = &oidcProvider{
+			name:         p.Name,
+			issuer:       strings.TrimSuffix(p.Issuer, "/"),
+			clientID:     p.ClientID,
+			clientSecret: p.ClientSecret,
+			client:       client,
+		}
+	}
+	return providers
+}
+
+// redirectURL is where the provider sends the user back to.
+func oidcRedirectURL(c *config.Config, provider string) string {
+	if c.Config.OIDCRedirectURL != "" {
+		return strings.ReplaceAll(c.Config.OIDCRedirectURL, "{provider}", provider)
+	}
+	return strings.TrimSuffix(c.Config.ApiURL, "/") + "/api/v1/oidc/" + provider + "/callback"
+}
+
+// pkceChallenge is the S256 code challenge of the verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (p *oidcProvider) getJSON(endpoint string, dest any) error {
+	resp, err := p.client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dest)
+}
+
+// endpoints returns the discovery document, fetching it when the cached one
+// is stale.
+func (p *oidcProvider) endpoints() (oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.discoveredAt.IsZero() && time.Since(p.discoveredAt) < oidcDiscoveryTTL {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	err := p.getJSON(p.issuer+"/.well-known/openid-configuration", &d)
+	if err != nil {
+		return d, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.issuer {
+		return d, fmt.Errorf("discovery issuer %q doesn't match %q", d.Issuer, p.issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return d, errors.New("discovery document misses endpoints")
+	}
+
+	p.discovery = d
+	p.discoveredAt = time.Now()
+	return d, nil
+}
+
+// authURL builds the authorization request of the code flow with PKCE.
+func (p *oidcProvider) authURL(redirectURL, state, nonce, verifier string) (string, error) {
+	d, err := p.endpoints()
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.clientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", pkceChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// exchange trades the authorization code for tokens and returns the
+// verified ID token claims.
+func (p *oidcProvider) exchange(code, redirectURL, verifier, nonce string) (idTokenClaims, error) {
+	var claims idTokenClaims
+
+	d, err := p.endpoints()
+	if err != nil {
+		return claims, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("client_id", p.clientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return claims, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return claims, err
+	}
+	defer resp.Body.Close()
+
+	var tokens oidcTokenResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens)
+	if err != nil {
+		return claims, err
+	}
+	if resp.StatusCode != http.StatusOK || tokens.IDToken == "" {
+		return claims, fmt.Errorf("token endpoint answered %d %s", resp.StatusCode, tokens.Error)
+	}
+
+	return p.verifyIDToken(tokens.IDToken, nonce, time.Now())
+}
+
+// verifyIDToken checks signature, issuer, audience, expiry and nonce.
+func (p *oidcProvider) verifyIDToken(token, nonce string, now time.Time) (idTokenClaims, error) {
+	var claims idTokenClaims
+	enc := base64.RawURLEncoding
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errInvalidIDToken
+	}
+
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return claims, errInvalidIDToken
+	}
+	var header jwtHeader
+	if err = json.Unmarshal(rawHeader, &header); err != nil {
+		return claims, errInvalidIDToken
+	}
+
+	key, err := p.key(header.Kid)
+	if err != nil {
+		return claims, err
+	}
+
+	signature, err := enc.DecodeString(parts[2])
+	if err != nil || !verifyJWS(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature) {
+		return claims, errInvalidIDToken
+	}
+
+	rawPayload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return claims, errInvalidIDToken
+	}
+	if err = json.Unmarshal(rawPayload, &claims); err != nil {
+		return claims, errInvalidIDToken
+	}
+
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != p.issuer,
+		claims.Subject == "",
+		!claims.hasAudience(p.clientID),
+		now.After(time.Unix(claims.ExpiresAt, 0).Add(oidcClockSkew)),
+		time.Unix(claims.IssuedAt, 0).After(now.Add(oidcClockSkew)),
+		claims.Nonce == "" || claims.Nonce != nonce:
+		return claims, errInvalidIDToken
+	}
+	return claims, nil
+}
+
+func (c idTokenClaims) hasAudience(clientID string) bool {
+	var single string
+	if json.Unmarshal(c.Audience, &single) == nil {
+		return single == clientID
+	}
+
+	var many []string
+	if json.Unmarshal(c.Audience, &many) != nil {
+		return false
+	}
+	for _, aud := range many {
+		if aud == clientID {
+			return len(many) == 1 || c.AuthorizedBy == clientID
+		}
+	}
+	return false
+}
+
+// emailVerified accepts true and "true", some providers send a string.
+func (c idTokenClaims) emailVerified() bool {
+	var b bool
+	if json.Unmarshal(c.EmailVerified, &b) == nil {
+		return b
+	}
+	var s string
+	return json.Unmarshal(c.EmailVerified, &s) == nil && s == "true"
+}
+
+// key returns the provider's signing key by kid. Keys are cached and
+// refetched when they are stale or the kid is unknown, the provider may
+// have rotated them.
+func (p *oidcProvider) key(kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	fresh := time.Since(p.keysFetchedAt) < oidcJWKSTTL
+	mayRefetch := time.Since(p.keysAttemptAt) >= oidcJWKSRefetch
+	p.mu.Unlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+	if !mayRefetch {
+		if ok {
+			return key, nil
+		}
+		return nil, errInvalidIDToken
+	}
+
+	d, err := p.endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keysAttemptAt = time.Now()
+	p.mu.Unlock()
+
+	var set struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	err = p.getJSON(d.JWKSURI, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, errInvalidIDToken
+	}
+	return key, nil
+}
+
+func (k oidcJWK) publicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding
+
+	switch k.Kty {
+	case "RSA":
+		n, err := dec.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 {
+			return nil, errors.New("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("unsupported curve")
+		}
+		x, err := dec.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return pub, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve")
+		}
+		x, err := dec.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("unsupported key type")
+}
+
+// verifyJWS checks a signature, the algorithm has to match the key type.
+func verifyJWS(alg string, key crypto.PublicKey, input, signature []byte) bool {
+	sum := sha256.Sum256(input)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, sum[:], r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(pub, input, signature)
+	}
+	return false
+}
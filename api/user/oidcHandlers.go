@@ -0,0 +1,216 @@
+package user
+
+import (
+	"crypto/subtle"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"strings"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// getOIDCProvidersFunc godoc
+//
+//	@Summary		Get external login providers
+//	@Description	Requests providers users can sign in with
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{array}	oidcProviderInfo	"OK"
+//	@Router			/oidc/providers [get]
+func getOIDCProvidersFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		providers := []oidcProviderInfo{}
+		for name := range s.oidc {
+			providers = append(providers, oidcProviderInfo{
+				Name:     name,
+				LoginURL: strings.TrimSuffix(s.Config.Config.ApiURL, "/") + "/api/v1/oidc/" + name + "/login",
+			})
+		}
+		sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+
+		w.WriteHeader(http.StatusOK)
+		service.OkResponse(w, providers)
+	}
+}
+
+// oidcLoginFunc godoc
+//
+//	@Summary		Sign in with external provider
+//	@Description	Redirects the browser to the provider's login page (authorization code flow with PKCE)
+//	@Tags			Auth
+//	@Param			provider	path	string	true	"provider name"
+//	@Success		302
+//	@Failure		404	{object}	service.errorResponse	"Not Found"
+//	@Failure		502	{object}	service.errorResponse	"Bad gateway"
+//	@Router			/oidc/{provider}/login [get]
+func oidcLoginFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("provider")
+		provider, ok := s.oidc[name]
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.OIDCProviderErr, name)
+			service.NotFoundResponse(w, service.OIDCProviderErr)
+			return
+		}
+
+		st := OIDCState{}
+		state, err := st.Create(s.DbWorker, name)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OIDCLoginErr, err)
+			service.InternalServerErrorResponse(w, service.OIDCLoginErr, err)
+			return
+		}
+
+		authURL, err := provider.authURL(oidcRedirectURL(s.Config, name), state, st.Nonce, st.Verifier)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			log.Error(service.OIDCProviderUnavailable, err)
+			service.OkResponse(w, service.DefaultResponse{
+				ResultCode: 1,
+				HttpCode:   http.StatusBadGateway,
+				Messages:   service.OIDCProviderUnavailable,
+				Data:       nil,
+			})
+			return
+		}
+
+		// The state also goes to a cookie, so the callback can only finish
+		// a login started in the same browser.
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookieName,
+			Value:    state,
+			Path:     "/api/v1/oidc/",
+			MaxAge:   int(oidcStateTTL.Seconds()),
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// oidcCallbackFunc godoc
+//
+//	@Summary		External provider callback
+//	@Description	The provider redirects here after login. Links the external account to the user with the same verified email or creates a new user, then logs in like /login does.
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			provider	path		string					true	"provider name"
+//	@Param			code		query		string					true	"authorization code"
+//	@Param			state		query		string					true	"state"
+//	@Success		200			{object}	service.DefaultResponse	"OK"
+//	@Failure		401			{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403			{object}	service.errorResponse	"Forbidden"
+//	@Failure		404			{object}	service.errorResponse	"Not Found"
+//	@Failure		409			{object}	service.errorResponse	"Conflict"
+//	@Failure		500			{object}	service.errorResponse	"Internal server error"
+//	@Router			/oidc/{provider}/callback [get]
+func oidcCallbackFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		name := r.PathValue("provider")
+		provider, ok := s.oidc[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			log.Error(service.OIDCProviderErr, name)
+			service.NotFoundResponse(w, service.OIDCProviderErr)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookieName,
+			Path:     "/api/v1/oidc/",
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		q := r.URL.Query()
+		if q.Get("error") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.OIDCLoginErr, q.Get("error"))
+			service.UnauthorizedResponse(w, q.Get("error"))
+			return
+		}
+
+		state := q.Get("state")
+		cookie, err := r.Cookie(oidcStateCookieName)
+		if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.OIDCStateErr)
+			service.UnauthorizedResponse(w, service.OIDCStateErr)
+			return
+		}
+
+		st := OIDCState{}
+		err = st.Take(s.DbWorker, state, name)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.Error(service.OIDCStateErr)
+				service.UnauthorizedResponse(w, service.OIDCStateErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OIDCLoginErr, err)
+			service.InternalServerErrorResponse(w, service.OIDCLoginErr, err)
+			return
+		}
+
+		claims, err := provider.exchange(q.Get("code"), oidcRedirectURL(s.Config, name), st.Verifier, st.Nonce)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.OIDCLoginErr, err)
+			service.UnauthorizedResponse(w, service.OIDCLoginErr)
+			return
+		}
+
+		usr, created, err := linkIdentity(s.DbWorker, name, claims)
+		if err != nil {
+			if errors.Is(err, errOIDCEmail) {
+				w.WriteHeader(http.StatusForbidden)
+				log.Error(service.OIDCEmailErr)
+				service.ForbiddenResponse(w, service.OIDCEmailErr)
+				return
+			}
+			if errors.Is(err, errOIDCUnverified) {
+				w.WriteHeader(http.StatusConflict)
+				log.Error(service.OIDCLinkErr)
+				service.ConflictResponse(w, service.OIDCLinkErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.OIDCLoginErr, err)
+			service.InternalServerErrorResponse(w, service.OIDCLoginErr, err)
+			return
+		}
+
+		if created {
+			audit.Record(s.DbWorker, r, audit.Event{
+				ActorUUID:  usr.UserUUID,
+				EntityKind: audit.KindUser,
+				EntityUUID: usr.UserUUID,
+				Action:     audit.ActionCreate,
+			}, nil, usr)
+		}
+
+		log.WithFields(log.Fields{
+			"id":       usr.UserUUID,
+			"provider": name,
+			"created":  created,
+		}).Info(service.OIDCLoginSuccess)
+
+		finishLogin(w, r, s, usr)
+	}
+}
@@ -0,0 +1,175 @@
+package user
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"todoApp/types"
+)
+
+const (
+	oidcStateTTL        = 10 * time.Minute
+	oidcStateCookieName = "oidc_state"
+)
+
+var (
+	errOIDCEmail      = errors.New("provider didn't return a verified email")
+	errOIDCUnverified = errors.New("local account with this email is not verified")
+)
+
+// OIDCState is one login attempt with an external provider. It keeps the
+// nonce and the PKCE verifier until the provider redirects back.
+type OIDCState struct {
+	ID        uint   `gorm:"primarykey"`
+	StateHash string `gorm:"uniqueIndex"`
+	Provider  string
+	Nonce     string
+	Verifier  string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// ExternalIdentity links a user to an account at an external provider.
+type ExternalIdentity struct {
+	ID        uint      `gorm:"primarykey"`
+	Provider  string    `gorm:"uniqueIndex:idx_external_identity"`
+	Subject   string    `gorm:"uniqueIndex:idx_external_identity"`
+	UserUUID  uuid.UUID `gorm:"index"`
+	Email     string
+	CreatedAt time.Time
+}
+
+type oidcProviderInfo struct {
+	Name     string `json:"name" example:"google" extensions:"x-order=1"`
+	LoginURL string `json:"loginUrl" extensions:"x-order=2"`
+}
+
+type oidcStateUsed struct {
+	UsedAt *time.Time
+}
+
+// Create starts a login attempt and returns the plain state for the
+// authorization request.
+func (o *OIDCState) Create(wrk dbWorker, provider string) (string, error) {
+	values := make([]string, 3)
+	for i := range values {
+		v, err := generateToken(32, nil)
+		if err != nil {
+			return "", err
+		}
+		values[i] = v
+	}
+
+	o.StateHash = hashKey(values[0])
+	o.Provider = provider
+	o.Nonce = values[1]
+	o.Verifier = values[2]
+	o.ExpiresAt = time.Now().Add(oidcStateTTL)
+
+	err := wrk.CreateRecord(o)
+	if err != nil {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// Take loads the attempt of the state and burns it, a state works once.
+func (o *OIDCState) Take(wrk dbWorker, state, provider string) error {
+	params := map[string]any{"state_hash": hashKey(state), "provider": provider, "used_at IS": nil}
+	err := wrk.ReadOneRecord(o, params)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(o.ExpiresAt) {
+		return errors.New("404")
+	}
+
+	now := time.Now()
+	err = wrk.UpdateRecordSubmodel(OIDCState{}, &oidcStateUsed{UsedAt: &now}, map[string]any{"id": o.ID, "used_at IS": nil})
+	if err != nil {
+		return err
+	}
+	o.UsedAt = &now
+	return nil
+}
+
+// linkIdentity finds the user behind the external identity. The first login
+// links it by verified email to an existing user or creates a new one.
+// created tells whether a user was created. An identity whose user is gone
+// is dropped and linked again like a new one.
+func linkIdentity(wrk dbWorker, provider string, claims idTokenClaims) (usr User, created bool, err error) {
+	identity := ExternalIdentity{}
+	err = wrk.ReadOneRecord(&identity, map[string]any{"provider": provider, "subject": claims.Subject})
+	if err == nil {
+		usr = User{UserUUID: identity.UserUUID}
+		err = usr.Read(wrk)
+		if err == nil || err.Error() != "404" {
+			return usr, false, err
+		}
+		err = wrk.DeleteRecord(&ExternalIdentity{}, map[string]any{"id": identity.ID})
+	}
+	if err != nil && err.Error() != "404" {
+		return usr, false, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.emailVerified() {
+		return usr, false, errOIDCEmail
+	}
+
+	err = wrk.Transaction(func(tx types.DatabaseWorker) error {
+		usr = User{Email: email}
+		err := usr.Read(tx)
+		switch {
+		case err == nil:
+			// Someone could have registered the address without owning it,
+			// linking to such an account would hand it over to them.
+			if !usr.EmailVerified {
+				return errOIDCUnverified
+			}
+		case err.Error() == "404":
+			usr, err = newExternalUser(tx, email, claims)
+			if err != nil {
+				return err
+			}
+			created = true
+		default:
+			return err
+		}
+
+		return tx.CreateRecord(&ExternalIdentity{
+			Provider: provider,
+			Subject:  claims.Subject,
+			UserUUID: usr.UserUUID,
+			Email:    email,
+		})
+	})
+	return usr, created, err
+}
+
+// newExternalUser creates a verified user with a random password, they can
+// set a real one with the password reset.
+func newExternalUser(wrk dbWorker, email string, claims idTokenClaims) (User, error) {
+	password, err := generateEmailVerificationKey()
+	if err != nil {
+		return User{}, err
+	}
+
+	username := claims.Username
+	if username == "" {
+		username, _, _ = strings.Cut(email, "@")
+	}
+
+	usr := User{
+		Email:         email,
+		EmailVerified: true,
+		Password:      password,
+		Username:      username,
+		Name:          claims.GivenName,
+		Surname:       claims.FamilyName,
+	}
+	err = usr.Create(wrk)
+	return usr, err
+}
@@ -0,0 +1,402 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+// forgotPasswordFunc     godoc
+//
+//	@Summary		Forgot password
+//	@Description	Sends a password reset link to the email. Answers 200 whether the email is known or not. Requests are throttled per IP and a user has at most 3 open reset links.
+//	@Tags			Password
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		forgotPasswordModel		true	"Email"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		429		{object}	service.errorResponse	"Too many requests"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/password/forgot [post]
+func forgotPasswordFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		f := forgotPasswordModel{}
+		err = service.DeserializeJSON(data, &f)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		ip := LoginFailure{Key: "reset:" + ipKey(r, s.Config.Config.TrustedProxyHeader)}
+		wait, err := ip.Claim(s.DbWorker, resetThrottle, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.LoginThrottleErr, err)
+			service.InternalServerErrorResponse(w, service.LoginThrottleErr, err)
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			w.WriteHeader(http.StatusTooManyRequests)
+			log.WithFields(log.Fields{
+				"ip": ip.Key,
+			}).Warn(service.PasswordResetLockedErr)
+			service.TooManyRequestsResponse(w, service.PasswordResetLockedErr)
+			return
+		}
+
+		// Sent in the background: waiting for SMTP only for known emails
+		// would tell them apart by response time.
+		email := strings.ToLower(strings.TrimSpace(f.Email))
+		if email != "" {
+			go func() {
+				err := sendPasswordReset(s, email)
+				if err != nil {
+					log.WithFields(log.Fields{
+						"email": email,
+					}).Error(service.PasswordResetErr, err)
+				}
+			}()
+		}
+
+		w.WriteHeader(http.StatusOK)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.PasswordResetSent,
+			Data:       nil,
+		})
+	}
+}
+
+// sendPasswordReset mails a reset link if the email belongs to a user.
+// Unknown emails are not an error, the caller must not tell them apart.
+func sendPasswordReset(s *Service, email string) error {
+	usr := User{Email: email}
+	err := usr.Read(s.DbWorker)
+	if err != nil {
+		if err.Error() == "404" {
+			log.WithFields(log.Fields{
+				"email": email,
+			}).Info(service.EmailNotFoundErr)
+			return nil
+		}
+		return err
+	}
+
+	reset := PasswordReset{UserUUID: usr.UserUUID}
+	key, err := reset.Create(s.DbWorker)
+	if err != nil {
+		if errors.Is(err, errTooManyResets) {
+			log.WithFields(log.Fields{
+				"id": usr.UserUUID,
+			}).Warn(service.PasswordResetLockedErr)
+			return nil
+		}
+		return err
+	}
+
+	type link struct {
+		Link string
+	}
+	l := link{Link: fmt.Sprintf("%s%s", s.Config.Config.ResetURL, key)}
+
+	err = service.SendEmail(s.Config, usr.Email, service.PasswordResetSubject, "static/passwordReset.html", l)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"id": usr.UserUUID,
+	}).Info(service.PasswordResetSent)
+	return nil
+}
+
+// revokeTokens drops the user's personal access tokens and revokes the
+// tokens issued to OAuth clients.
+func revokeTokens(wrk dbWorker, userUuid uuid.UUID) error {
+	return wrk.Transaction(func(tx types.DatabaseWorker) error {
+		pat := AccessToken{UserUUID: userUuid}
+		err := pat.DeleteAll(tx)
+		if err != nil {
+			return err
+		}
+		oauth := OAuthToken{UserUUID: userUuid}
+		return oauth.RevokeAll(tx)
+	})
+}
+
+// resetPasswordFunc     godoc
+//
+//	@Summary		Reset password
+//	@Description	Sets a new password using the key from the reset email. The key works once and expires in an hour. All sessions, personal access tokens and OAuth tokens of the user are revoked.
+//	@Tags			Password
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		resetPasswordModel		true	"Key and new password"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/password/reset [post]
+func resetPasswordFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		rp := resetPasswordModel{}
+		err = service.DeserializeJSON(data, &rp)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		reset := PasswordReset{}
+		err = reset.Find(s.DbWorker, rp.Key)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.PasswordResetKeyErr)
+				service.NotFoundResponse(w, service.PasswordResetKeyErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.PasswordResetErr, err)
+			service.InternalServerErrorResponse(w, service.PasswordResetErr, err)
+			return
+		}
+
+		usr := User{UserUUID: reset.UserUUID}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
+		violations := s.policy.Check(rp.NewPassword, usr.Email, usr.Username)
+		if len(violations) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.PasswordPolicyErr, violations)
+			service.BadRequestDetailsResponse(w, service.PasswordPolicyErr, violations)
+			return
+		}
+
+		err = reset.MarkUsed(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.PasswordResetKeyErr)
+				service.NotFoundResponse(w, service.PasswordResetKeyErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.PasswordResetErr, err)
+			service.InternalServerErrorResponse(w, service.PasswordResetErr, err)
+			return
+		}
+
+		err = usr.SetPassword(s.DbWorker, rp.NewPassword)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.PasswordResetErr, err)
+			service.InternalServerErrorResponse(w, service.PasswordResetErr, err)
+			return
+		}
+
+		session := Session{UserUuid: reset.UserUUID}
+		err = session.DeleteAllExceptOne(s.DbWorker, "")
+		if err != nil && err.Error() != "404" {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SessionCloseErr, err)
+			service.InternalServerErrorResponse(w, service.SessionCloseErr, err)
+			return
+		}
+
+		// Whoever took over the account may have minted tokens, they must
+		// not outlive the reset.
+		err = revokeTokens(s.DbWorker, reset.UserUUID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TokenRevokeErr, err)
+			service.InternalServerErrorResponse(w, service.TokenRevokeErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  reset.UserUUID,
+			EntityKind: audit.KindUser,
+			EntityUUID: reset.UserUUID,
+			Action:     audit.ActionUpdate,
+		}, nil, map[string]any{"passwordReset": true})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": reset.UserUUID,
+		}).Info(service.PasswordResetSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.PasswordResetSuccess,
+			Data:       nil,
+		})
+	}
+}
+
+// changePasswordFunc     godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Change password
+//	@Description	Sets a new password after checking the current one. All other sessions of the user are closed, with revokeTokens personal access tokens and OAuth tokens too.
+//	@Tags			Password
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		changePasswordModel		true	"Current and new password"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403		{object}	service.errorResponse	"Forbidden"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/password [put]
+func changePasswordFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		cp := changePasswordModel{}
+		err = service.DeserializeJSON(data, &cp)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		usr := User{UserUUID: session.UserUuid}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
+		err = comparePasswords(usr.Password, cp.CurrentPassword)
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			log.Error(service.WrongPasswordErr, err)
+			service.ForbiddenResponse(w, service.WrongPasswordErr)
+			return
+		}
+
+		violations := s.policy.Check(cp.NewPassword, usr.Email, usr.Username)
+		if len(violations) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.PasswordPolicyErr, violations)
+			service.BadRequestDetailsResponse(w, service.PasswordPolicyErr, violations)
+			return
+		}
+
+		err = usr.SetPassword(s.DbWorker, cp.NewPassword)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.PasswordChangeErr, err)
+			service.InternalServerErrorResponse(w, service.PasswordChangeErr, err)
+			return
+		}
+
+		err = session.DeleteOthers(s.DbWorker)
+		if err != nil && err.Error() != "404" {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SessionCloseErr, err)
+			service.InternalServerErrorResponse(w, service.SessionCloseErr, err)
+			return
+		}
+
+		if cp.RevokeTokens {
+			err = revokeTokens(s.DbWorker, usr.UserUUID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				log.Error(service.TokenRevokeErr, err)
+				service.InternalServerErrorResponse(w, service.TokenRevokeErr, err)
+				return
+			}
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  usr.UserUUID,
+			EntityKind: audit.KindUser,
+			EntityUUID: usr.UserUUID,
+			Action:     audit.ActionUpdate,
+		}, nil, map[string]any{"passwordChanged": true})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": usr.UserUUID,
+		}).Info(service.PasswordChangeSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.PasswordChangeSuccess,
+			Data:       nil,
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package user
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+	"todoApp/types"
+)
+
+const (
+	resetKeyTTL = time.Hour
+	// maxOpenResets caps the unused, unexpired keys of one user, so the
+	// forgot endpoint can't be used to flood an inbox.
+	maxOpenResets = 3
+)
+
+var errTooManyResets = errors.New("too many open reset keys")
+
+// PasswordReset is a single-use key sent by email to set a new password.
+// Only the hash of the key is stored.
+type PasswordReset struct {
+	ID        uint      `gorm:"primarykey"`
+	UserUUID  uuid.UUID `gorm:"index"`
+	KeyHash   string    `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type forgotPasswordModel struct {
+	Email string `json:"email" example:"example@email.box" extensions:"x-order=1"`
+}
+
+type resetPasswordModel struct {
+	Key         string `json:"key" extensions:"x-order=1"`
+	NewPassword string `json:"newPassword" example:"Very!Strong1Pa$$word" extensions:"x-order=2"`
+}
+
+type changePasswordModel struct {
+	CurrentPassword string `json:"currentPassword" extensions:"x-order=1"`
+	NewPassword     string `json:"newPassword" example:"Very!Strong1Pa$$word" extensions:"x-order=2"`
+	RevokeTokens    bool   `json:"revokeTokens" extensions:"x-order=3"`
+}
+
+type resetUsed struct {
+	UsedAt *time.Time
+}
+
+// Create issues a new reset key for the user and returns its plain value.
+// It fails with errTooManyResets while the user has maxOpenResets open keys.
+func (p *PasswordReset) Create(wrk dbWorker) (string, error) {
+	var open struct{ Count int64 }
+	err := wrk.Aggregate(PasswordReset{}, &open, map[string]any{
+		"select":       "count(*) AS count",
+		"user_uuid":    p.UserUUID,
+		"used_at IS":   nil,
+		"expires_at >": time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if open.Count >= maxOpenResets {
+		return "", errTooManyResets
+	}
+
+	key, err := generateEmailVerificationKey()
+	if err != nil {
+		return "", err
+	}
+
+	p.KeyHash = hashKey(key)
+	p.ExpiresAt = time.Now().Add(resetKeyTTL)
+
+	err = wrk.CreateRecord(p)
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Find loads an unused, unexpired reset by its plain key. Unknown, used and
+// expired keys all look the same to the caller.
+func (p *PasswordReset) Find(wrk dbWorker, key string) error {
+	params := map[string]any{"key_hash": hashKey(key), "used_at IS": nil}
+	err := wrk.ReadOneRecord(p, params)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(p.ExpiresAt) {
+		return errors.New("404")
+	}
+	return nil
+}
+
+// MarkUsed burns the key together with every other open key of the user,
+// so an older reset email can't be used once the password was set. Only one
+// of concurrent requests with the same key succeeds, the others get a 404.
+func (p *PasswordReset) MarkUsed(wrk dbWorker) error {
+	now := time.Now()
+	err := wrk.Transaction(func(tx types.DatabaseWorker) error {
+		params := map[string]any{"id": p.ID, "used_at IS": nil}
+		err := tx.UpdateRecordSubmodel(PasswordReset{}, &resetUsed{UsedAt: &now}, params)
+		if err != nil {
+			return err
+		}
+
+		params = map[string]any{"user_uuid": p.UserUUID, "used_at IS": nil}
+		err = tx.UpdateRecordSubmodel(PasswordReset{}, &resetUsed{UsedAt: &now}, params)
+		if err != nil && err.Error() != "404" {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.UsedAt = &now
+	return nil
+}
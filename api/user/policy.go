@@ -0,0 +1,284 @@
+package user
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"todoApp/api/service"
+	"todoApp/config"
+	"unicode"
+)
+
+// bcrypt ignores everything past 72 bytes, so longer passwords are refused
+// instead of being silently truncated.
+const bcryptMaxBytes = 72
+
+const (
+	classLower  = "lower"
+	classUpper  = "upper"
+	classDigit  = "digit"
+	classSymbol = "symbol"
+)
+
+// PolicyViolation is one failed password rule, sent to the client in the
+// data of a 400 response.
+type PolicyViolation struct {
+	Rule    string `json:"rule" example:"minLength"`
+	Message string `json:"message" example:"password has to be at least 8 characters long"`
+}
+
+type passwordPolicy struct {
+	minLength     int
+	classes       []string
+	checkPersonal bool
+	breached      *breachedList
+}
+
+// newPasswordPolicy reads the policy from the config. Unset values fall back
+// to: 8 chars min, no required classes, personal data check on, no
+// breached list. A configured breached list that can't be opened is an
+// error, the check must not silently turn off.
+func newPasswordPolicy(c *config.Config) (*passwordPolicy, error) {
+	p := &passwordPolicy{minLength: 8, checkPersonal: true}
+
+	if n, err := strconv.Atoi(c.Config.PasswordMinLength); err == nil && n > 0 {
+		p.minLength = min(n, bcryptMaxBytes)
+	}
+
+	for _, class := range strings.Split(c.Config.PasswordClasses, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch class {
+		case classLower, classUpper, classDigit, classSymbol:
+			p.classes = append(p.classes, class)
+		case "":
+		default:
+			log.Warn(service.PasswordPolicyConfigErr, "unknown character class ", class)
+		}
+	}
+
+	if v, err := strconv.ParseBool(c.Config.PasswordCheckPersonal); err == nil {
+		p.checkPersonal = v
+	}
+
+	if c.Config.PasswordBreachedFile != "" {
+		breached, err := openBreachedList(c.Config.PasswordBreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = breached
+	}
+
+	return p, nil
+}
+
+// breachedList looks SHA-1 hashes of leaked passwords up on disk, nothing
+// is loaded into memory, so full HIBP dumps work. path is either
+//   - a directory of range files, one per 5 char prefix (ABCDE.txt holding
+//     SUFFIX:COUNT lines), the layout of k-anonymity range lookups, or
+//   - a single file sorted by hash (HASH or HASH:COUNT lines), like the
+//     HIBP "ordered by hash" dump, which is binary searched.
+type breachedList struct {
+	path string
+	dir  bool
+}
+
+func openBreachedList(path string) (*breachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &breachedList{path: path, dir: info.IsDir()}, nil
+}
+
+// contains reports whether the upper case hex hash is on the list.
+func (b *breachedList) contains(hash string) (bool, error) {
+	if b.dir {
+		return b.containsInRange(hash)
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return searchSorted(f, info.Size(), hash)
+}
+
+func (b *breachedList) containsInRange(hash string) (bool, error) {
+	prefix, suffix := hash[:5], hash[5:]
+	f, err := os.Open(filepath.Join(b.path, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h := lineHash(scanner.Text())
+		if h == suffix || h == hash {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// searchSorted binary searches a file of lines sorted by hash. Lines
+// starting in [lo, hi) are the ones still in question.
+func searchSorted(r io.ReaderAt, size int64, hash string) (bool, error) {
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, next, err := lineAt(r, size, mid)
+		if err != nil {
+			return false, err
+		}
+		if next < 0 {
+			hi = mid
+			continue
+		}
+
+		switch h := lineHash(line); {
+		case h == hash:
+			return true, nil
+		case h < hash:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineAt returns the first line starting at or after offset and the offset
+// right after it. next is -1 when no line starts there.
+func lineAt(r io.ReaderAt, size, offset int64) (string, int64, error) {
+	start := max(offset-1, 0)
+	br := bufio.NewReaderSize(io.NewSectionReader(r, start, size-start), 128)
+
+	if offset > 0 {
+		skipped, err := br.ReadString('\n')
+		if err == io.EOF {
+			return "", -1, nil
+		}
+		if err != nil {
+			return "", -1, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", -1, err
+	}
+	if line == "" {
+		return "", -1, nil
+	}
+	return line, start + int64(len(line)), nil
+}
+
+// lineHash takes the hash of a HASH or HASH:COUNT line.
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
+
+func (p *passwordPolicy) isBreached(password string) bool {
+	if p.breached == nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	found, err := p.breached.contains(hash)
+	if err != nil {
+		log.Error(service.PasswordPolicyConfigErr, err)
+	}
+	return found
+}
+
+// Check returns every rule the password breaks, nil if it is fine. Email
+// and username are used to refuse passwords built from them.
+func (p *passwordPolicy) Check(password, email, username string) []PolicyViolation {
+	var violations []PolicyViolation
+
+	if len([]rune(password)) < p.minLength {
+		violations = append(violations, PolicyViolation{
+			Rule:    "minLength",
+			Message: fmt.Sprintf("password has to be at least %d characters long", p.minLength),
+		})
+	}
+	if len(password) > bcryptMaxBytes {
+		violations = append(violations, PolicyViolation{
+			Rule:    "maxLength",
+			Message: fmt.Sprintf("password is too long (MAX=%d bytes)", bcryptMaxBytes),
+		})
+	}
+
+	for _, class := range p.classes {
+		if !hasClass(password, class) {
+			violations = append(violations, PolicyViolation{
+				Rule:    "class",
+				Message: fmt.Sprintf("password has to contain at least one %s character", class),
+			})
+		}
+	}
+
+	if p.checkPersonal {
+		lower := strings.ToLower(password)
+		local, _, _ := strings.Cut(strings.ToLower(email), "@")
+		for _, part := range []string{local, strings.ToLower(username)} {
+			if len(part) >= 3 && strings.Contains(lower, part) {
+				violations = append(violations, PolicyViolation{
+					Rule:    "personal",
+					Message: "password can't contain your email or username",
+				})
+				break
+			}
+		}
+	}
+
+	if p.isBreached(password) {
+		violations = append(violations, PolicyViolation{
+			Rule:    "breached",
+			Message: "password was found in a data breach, choose another one",
+		})
+	}
+
+	return violations
+}
+
+func hasClass(password, class string) bool {
+	for _, r := range password {
+		switch {
+		case class == classLower && unicode.IsLower(r),
+			class == classUpper && unicode.IsUpper(r),
+			class == classDigit && unicode.IsDigit(r),
+			class == classSymbol && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package user
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"slices"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+	"todoApp/types"
+)
+
+type roleModel struct {
+	Role string `json:"role" example:"support" extensions:"x-order=1"`
+}
+
+type roleInfo struct {
+	Role        string             `json:"role" example:"support" extensions:"x-order=1"`
+	Permissions []types.Permission `json:"permissions" extensions:"x-order=2"`
+}
+
+type roleUpdate struct {
+	Role string
+}
+
+// requirePermission authenticates the request and checks the caller's role
+// grants p. On failure it answers the request itself.
+func requirePermission(w http.ResponseWriter, r *http.Request, s *Service, p types.Permission) (types.AuthUser, bool) {
+	token, err := service.ReadToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.TokenReadErr, err)
+		service.UnauthorizedResponse(w, "")
+		return types.AuthUser{}, false
+	}
+
+	authUser, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		log.Error(service.TokenValidationErr, err)
+		service.UnauthorizedResponse(w, "")
+		return authUser, false
+	}
+
+	if !authUser.Allows("", r.Method != http.MethodGet && r.Method != http.MethodHead) || !authUser.Can(p) {
+		w.WriteHeader(http.StatusForbidden)
+		log.WithFields(log.Fields{
+			"id":         authUser.UserUUID,
+			"permission": p,
+		}).Error(service.Forbidden)
+		service.ForbiddenResponse(w, service.PermissionErr)
+		return authUser, false
+	}
+	return authUser, true
+}
+
+// getRolesFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get roles
+//	@Description	Requests roles and the permissions they grant. Needs the roles:assign permission.
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{array}		roleInfo				"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403	{object}	service.errorResponse	"Forbidden"
+//	@Router			/admin/roles [get]
+func getRolesFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		_, ok := requirePermission(w, r, s, types.PermRolesAssign)
+		if !ok {
+			return
+		}
+
+		roles := []roleInfo{}
+		for role, permissions := range types.RolePermissions {
+			roles = append(roles, roleInfo{Role: role, Permissions: permissions})
+		}
+		slices.SortFunc(roles, func(a, b roleInfo) int { return len(b.Permissions) - len(a.Permissions) })
+
+		w.WriteHeader(http.StatusOK)
+		service.OkResponse(w, roles)
+	}
+}
+
+// assignRoleFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Assign role
+//	@Description	Sets the role of a user: admin, support or user. Needs the roles:assign permission. Nobody can change their own role.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"user uuid"
+//	@Param			data	body		roleModel				true	"Role"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403		{object}	service.errorResponse	"Forbidden"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		409		{object}	service.errorResponse	"Conflict"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/admin/users/{id}/role [put]
+func assignRoleFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		actor, ok := requirePermission(w, r, s, types.PermRolesAssign)
+		if !ok {
+			return
+		}
+
+		target, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.UUIDParseErr, err)
+			service.BadRequestResponse(w, service.UUIDParseErr, err)
+			return
+		}
+
+		// An admin demoting themselves could leave nobody to manage roles.
+		if target == actor.UserUUID {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.OwnRoleErr)
+			service.ConflictResponse(w, service.OwnRoleErr)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		rm := roleModel{}
+		err = service.DeserializeJSON(data, &rm)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		if _, ok := types.RolePermissions[rm.Role]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.RoleErr, rm.Role)
+			service.BadRequestResponse(w, service.RoleErr, rm.Role)
+			return
+		}
+
+		usr := User{UserUUID: target}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
+		err = s.DbWorker.UpdateRecordSubmodel(User{}, &roleUpdate{Role: rm.Role}, map[string]any{"user_uuid": target})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.RoleAssignErr, err)
+			service.InternalServerErrorResponse(w, service.RoleAssignErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  actor.UserUUID,
+			EntityKind: audit.KindUser,
+			EntityUUID: target,
+			Action:     audit.ActionUpdate,
+		}, map[string]any{"role": usr.Role}, map[string]any{"role": rm.Role})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":    target,
+			"actor": actor.UserUUID,
+			"role":  rm.Role,
+		}).Info(service.RoleAssignSuccess)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.RoleAssignSuccess,
+			Data:       nil,
+		})
+	}
+}
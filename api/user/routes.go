@@ -13,21 +13,99 @@ func addRoutes(s *Service) {
 	deleteUserHandler := deleteUserFunc(s)
 	s.Router.HandleFunc("DELETE /api/v1/user/{id}", deleteUserHandler)
 
+	getRolesHandler := getRolesFunc(s)
+	s.Router.HandleFunc("GET /api/v1/admin/roles", getRolesHandler)
+
+	assignRoleHandler := assignRoleFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/admin/users/{id}/role", assignRoleHandler)
+
 	loginHandler := loginFunc(s)
 	s.Router.HandleFunc("POST /api/v1/login", loginHandler)
 
+	unlockAccountHandler := unlockAccountFunc(s)
+	s.Router.HandleFunc("POST /api/v1/login/unlock/{key}", unlockAccountHandler)
+
+	loginTwoFactorHandler := loginTwoFactorFunc(s)
+	s.Router.HandleFunc("POST /api/v1/login/2fa", loginTwoFactorHandler)
+
+	enrollTwoFactorHandler := enrollTwoFactorFunc(s)
+	s.Router.HandleFunc("POST /api/v1/me/2fa/enroll", enrollTwoFactorHandler)
+
+	confirmTwoFactorHandler := confirmTwoFactorFunc(s)
+	s.Router.HandleFunc("POST /api/v1/me/2fa/confirm", confirmTwoFactorHandler)
+
+	disableTwoFactorHandler := disableTwoFactorFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/me/2fa", disableTwoFactorHandler)
+
+	oidcProvidersHandler := getOIDCProvidersFunc(s)
+	s.Router.HandleFunc("GET /api/v1/oidc/providers", oidcProvidersHandler)
+
+	oidcLoginHandler := oidcLoginFunc(s)
+	s.Router.HandleFunc("GET /api/v1/oidc/{provider}/login", oidcLoginHandler)
+
+	oidcCallbackHandler := oidcCallbackFunc(s)
+	s.Router.HandleFunc("GET /api/v1/oidc/{provider}/callback", oidcCallbackHandler)
+
+	createOAuthClientHandler := createOAuthClientFunc(s)
+	s.Router.HandleFunc("POST /api/v1/oauth/clients", createOAuthClientHandler)
+
+	getOAuthClientsHandler := getOAuthClientsFunc(s)
+	s.Router.HandleFunc("GET /api/v1/oauth/clients", getOAuthClientsHandler)
+
+	deleteOAuthClientHandler := deleteOAuthClientFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/oauth/clients/{clientId}", deleteOAuthClientHandler)
+
+	authorizeHandler := authorizeFunc(s)
+	s.Router.HandleFunc("GET /api/v1/oauth/authorize", authorizeHandler)
+
+	consentHandler := consentFunc(s)
+	s.Router.HandleFunc("POST /api/v1/oauth/authorize", consentHandler)
+
+	oauthTokenHandler := oauthTokenFunc(s)
+	s.Router.HandleFunc("POST /api/v1/oauth/token", oauthTokenHandler)
+
+	oauthIntrospectHandler := oauthIntrospectFunc(s)
+	s.Router.HandleFunc("POST /api/v1/oauth/introspect", oauthIntrospectHandler)
+
+	refreshTokenHandler := refreshTokenFunc(s)
+	s.Router.HandleFunc("POST /api/v1/token/refresh", refreshTokenHandler)
+
 	logoutHandler := logoutFunc(s)
 	s.Router.HandleFunc("POST /api/v1/logout", logoutHandler)
 
 	meHandler := meFunc(s)
 	s.Router.HandleFunc("GET /api/v1/me", meHandler)
 
+	getSettingsHandler := getSettingsFunc(s)
+	s.Router.HandleFunc("GET /api/v1/me/settings", getSettingsHandler)
+
+	updateSettingsHandler := updateSettingsFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/me/settings", updateSettingsHandler)
+
 	emailHandler := emailFunc(s)
 	s.Router.HandleFunc("POST /api/v1/verifyEmail/{key}", emailHandler)
 
 	emailResendHandler := emailResendFunc(s)
 	s.Router.HandleFunc("POST /api/v1/reVerifyEmail/{email}", emailResendHandler)
 
+	changePasswordHandler := changePasswordFunc(s)
+	s.Router.HandleFunc("PUT /api/v1/me/password", changePasswordHandler)
+
+	createAccessTokenHandler := createAccessTokenFunc(s)
+	s.Router.HandleFunc("POST /api/v1/me/tokens", createAccessTokenHandler)
+
+	getAccessTokensHandler := getAccessTokensFunc(s)
+	s.Router.HandleFunc("GET /api/v1/me/tokens", getAccessTokensHandler)
+
+	deleteAccessTokenHandler := deleteAccessTokenFunc(s)
+	s.Router.HandleFunc("DELETE /api/v1/me/tokens/{tokenId}", deleteAccessTokenHandler)
+
+	forgotPasswordHandler := forgotPasswordFunc(s)
+	s.Router.HandleFunc("POST /api/v1/password/forgot", forgotPasswordHandler)
+
+	resetPasswordHandler := resetPasswordFunc(s)
+	s.Router.HandleFunc("POST /api/v1/password/reset", resetPasswordHandler)
+
 	getAllSessionsHandler := getAllSessionsFunc(s)
 	s.Router.HandleFunc("GET /api/v1/getAllSessions", getAllSessionsHandler)
 
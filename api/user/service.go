@@ -2,6 +2,7 @@ package user
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
@@ -30,3 +31,10 @@ func generateEmailVerificationKey() (string, error) {
 
 	return value, nil
 }
+
+// hashKey is used to store one-time keys, only the receiver knows the plain
+// value.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,20 +3,47 @@ package user
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"net/http"
 	"time"
 	"todoApp/api/service"
+	"todoApp/types"
 )
 
+// errRefreshReuse means a refresh token was presented after it had been
+// rotated, so it has most likely leaked.
+var errRefreshReuse = errors.New("refresh token reuse")
+
+// Session is either a cookie session or, in JWT mode, a refresh token. Refresh
+// tokens of one login share a Family, every refresh rotates the token and
+// marks the old row with RotatedAt.
 type Session struct {
 	gorm.Model `json:"-"`
-	UserUuid   uuid.UUID `json:"-"`
-	Token      string    `json:"-"`
-	ClientInfo string    `json:"clientInfo"`
-	Expires    time.Time `json:"expires"`
+	UserUuid   uuid.UUID  `json:"-"`
+	Token      string     `json:"-"`
+	ClientInfo string     `json:"clientInfo"`
+	Expires    time.Time  `json:"expires"`
+	Family     *uuid.UUID `gorm:"index" json:"-"`
+	RotatedAt  *time.Time `json:"-"`
+}
+
+type refreshModel struct {
+	RefreshToken string `json:"refreshToken" extensions:"x-order=1"`
+}
+
+type tokenPair struct {
+	UserUUID     uuid.UUID `json:"userId" extensions:"x-order=1"`
+	AccessToken  string    `json:"accessToken" extensions:"x-order=2"`
+	TokenType    string    `json:"tokenType" example:"Bearer" extensions:"x-order=3"`
+	ExpiresIn    int       `json:"expiresIn" example:"900" extensions:"x-order=4"`
+	RefreshToken string    `json:"refreshToken" extensions:"x-order=5"`
+}
+
+type sessionRotated struct {
+	RotatedAt *time.Time
 }
 
 func (s *Session) Create(wrk dbWorker, userUuid uuid.UUID, salt []byte, userAgent string) (http.Cookie, error) {
@@ -46,7 +73,7 @@ func (s *Session) Create(wrk dbWorker, userUuid uuid.UUID, salt []byte, userAgen
 }
 
 func (s *Session) Read(wrk dbWorker) error {
-	params := map[string]any{service.SessionTokenName: s.Token}
+	params := map[string]any{service.SessionTokenName: s.Token, "family IS": nil}
 	err := wrk.ReadOneRecord(&s, params)
 	if err != nil {
 		return err
@@ -56,7 +83,7 @@ func (s *Session) Read(wrk dbWorker) error {
 
 func (s *Session) ReadAll(wrk dbWorker) ([]Session, error) {
 	var allSessions []Session
-	params := map[string]any{"user_uuid": s.UserUuid}
+	params := map[string]any{"user_uuid": s.UserUuid, "rotated_at IS": nil}
 	err := wrk.ReadManyRecords(Session{}, &allSessions, params)
 	if err != nil {
 		return nil, err
@@ -87,6 +114,104 @@ func (s *Session) DeleteAllExceptOne(wrk dbWorker, except string) error {
 	return nil
 }
 
+// DeleteOthers ends every other login of the user. In JWT mode the whole
+// family of s is kept: its rotated tokens are what Rotate recognises a
+// reused refresh token by.
+func (s *Session) DeleteOthers(wrk dbWorker) error {
+	if s.Family == nil {
+		return s.DeleteAllExceptOne(wrk, s.Token)
+	}
+	params := map[string]any{"user_uuid": s.UserUuid, "family": s.Family}
+	return wrk.DeleteManyExceptOne(s, params)
+}
+
+// CreateRefresh starts a new refresh token family and returns the token.
+func (s *Session) CreateRefresh(wrk dbWorker, userUuid uuid.UUID, salt []byte, userAgent string, ttl time.Duration) (string, error) {
+	token, err := generateToken(32, salt)
+	if err != nil {
+		return "", err
+	}
+
+	family := uuid.New()
+
+	s.UserUuid = userUuid
+	s.Token = token
+	s.ClientInfo = userAgent
+	s.Expires = time.Now().Add(ttl)
+	s.Family = &family
+
+	err = wrk.CreateRecord(s)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Rotate exchanges a refresh token for a new one of the same family. Using a
+// rotated token again revokes the whole family and returns errRefreshReuse.
+func (s *Session) Rotate(wrk dbWorker, token string, salt []byte, ttl time.Duration) (string, error) {
+	old := Session{}
+	err := wrk.ReadOneRecord(&old, map[string]any{service.SessionTokenName: token, "family IS NOT": nil})
+	if err != nil {
+		return "", err
+	}
+
+	if old.RotatedAt != nil {
+		s.Family = old.Family
+		err = s.DeleteFamily(wrk)
+		if err != nil && err.Error() != "404" {
+			return "", err
+		}
+		return "", errRefreshReuse
+	}
+	if time.Now().After(old.Expires) {
+		return "", errors.New("404")
+	}
+
+	newToken, err := generateToken(32, salt)
+	if err != nil {
+		return "", err
+	}
+
+	err = wrk.Transaction(func(tx types.DatabaseWorker) error {
+		now := time.Now()
+		params := map[string]any{"id": old.ID, "rotated_at IS": nil}
+		err := tx.UpdateRecordSubmodel(Session{}, &sessionRotated{RotatedAt: &now}, params)
+		if err != nil {
+			if err.Error() == "404" {
+				return errRefreshReuse
+			}
+			return err
+		}
+
+		s.UserUuid = old.UserUuid
+		s.Token = newToken
+		s.ClientInfo = old.ClientInfo
+		s.Expires = now.Add(ttl)
+		s.Family = old.Family
+		return tx.CreateRecord(s)
+	})
+	if errors.Is(err, errRefreshReuse) {
+		s.Family = old.Family
+		_ = s.DeleteFamily(wrk)
+	}
+	if err != nil {
+		return "", err
+	}
+	return newToken, nil
+}
+
+// ReadFamily loads the current refresh token of s.Family.
+func (s *Session) ReadFamily(wrk dbWorker) error {
+	params := map[string]any{"family": s.Family, "rotated_at IS": nil}
+	return wrk.ReadOneRecord(s, params)
+}
+
+// DeleteFamily revokes every refresh token of s.Family.
+func (s *Session) DeleteFamily(wrk dbWorker) error {
+	return wrk.DeleteRecord(&Session{}, map[string]any{"family": s.Family})
+}
+
 func createSessionCookie(token string, expires time.Time) (http.Cookie, error) {
 	cookie := http.Cookie{
 		Name:        service.SessionTokenName,
@@ -99,9 +224,8 @@ func createSessionCookie(token string, expires time.Time) (http.Cookie, error) {
 		Partitioned: true,
 	}
 	log.WithFields(log.Fields{
-		"name":  service.SessionTokenName,
-		"value": token,
-	}).Debug()
+		"name": service.SessionTokenName,
+	}).Debug("Session cookie created")
 	return cookie, nil
 }
 
@@ -114,11 +238,5 @@ func generateToken(length int, salt []byte) (string, error) {
 	}
 
 	bytes = append(bytes, salt...)
-	value := hex.EncodeToString(bytes)
-
-	log.WithFields(log.Fields{
-		"value": value,
-	}).Debug("Generated token")
-
-	return value, nil
+	return hex.EncodeToString(bytes), nil
 }
@@ -0,0 +1,160 @@
+package user
+
+import (
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// getSettingsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Get user settings
+//	@Description	Requests user's time zone, locale, week start (0 is Sunday) and date format
+//	@Tags			User
+//	@Produce		json
+//	@Success		200	{object}	UserSettings			"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/settings [get]
+func getSettingsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		st := UserSettings{UserUUID: session.UserUuid}
+		err = st.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.Info(service.SettingsReadSuccess)
+		service.OkResponse(w, st)
+	}
+}
+
+// updateSettingsFunc godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Update user settings
+//	@Description	Saves user's time zone (IANA name), locale, week start (0 is Sunday) and date format (dd-mm-yyyy, dd.mm.yyyy, mm/dd/yyyy or yyyy-mm-dd)
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		updateUserSettings		true	"Settings"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/settings [put]
+func updateSettingsFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		before := UserSettings{UserUUID: session.UserUuid}
+		err = before.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsReadErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsReadErr, err)
+			return
+		}
+
+		upd := updateUserSettings{
+			UserUUID:   session.UserUuid,
+			Timezone:   before.Timezone,
+			Locale:     before.Locale,
+			WeekStart:  before.WeekStart,
+			DateFormat: before.DateFormat,
+		}
+		err = service.DeserializeJSON(data, &upd)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		err = upd.validate()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.ValidationErr, err)
+			service.BadRequestResponse(w, service.ValidationErr, err)
+			return
+		}
+
+		err = upd.Save(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.SettingsUpdateErr, err)
+			service.InternalServerErrorResponse(w, service.SettingsUpdateErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindUser,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionUpdate,
+		}, before, upd)
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id":       session.UserUuid,
+			"timezone": upd.Timezone,
+		}).Info(service.SettingsUpdateSuccess)
+
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.SettingsUpdateSuccess,
+			Data:       nil,
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"regexp"
+	"time"
+)
+
+// dateFormats maps date formats users can pick to Go layouts.
+var dateFormats = map[string]string{
+	"dd-mm-yyyy": "02-01-2006",
+	"dd.mm.yyyy": "02.01.2006",
+	"mm/dd/yyyy": "01/02/2006",
+	"yyyy-mm-dd": "2006-01-02",
+}
+
+var localeRegexp = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+const (
+	defaultTimezone   = "UTC"
+	defaultLocale     = "en"
+	defaultWeekStart  = 1
+	defaultDateFormat = "dd-mm-yyyy"
+)
+
+// UserSettings holds user's display preferences. Week start is a day number,
+// 0 is Sunday.
+type UserSettings struct {
+	gorm.Model `json:"-"`
+	UserUUID   uuid.UUID `json:"-" gorm:"uniqueIndex"`
+	Timezone   string    `json:"timezone" example:"Europe/Berlin" extensions:"x-order=1"`
+	Locale     string    `json:"locale" example:"en-US" extensions:"x-order=2"`
+	WeekStart  int       `json:"weekStart" example:"1" extensions:"x-order=3"`
+	DateFormat string    `json:"dateFormat" example:"dd-mm-yyyy" extensions:"x-order=4"`
+}
+
+type updateUserSettings struct {
+	UserUUID   uuid.UUID `json:"-"`
+	Timezone   string    `json:"timezone" example:"Europe/Berlin" extensions:"x-order=1"`
+	Locale     string    `json:"locale" example:"en-US" extensions:"x-order=2"`
+	WeekStart  int       `json:"weekStart" example:"1" extensions:"x-order=3"`
+	DateFormat string    `json:"dateFormat" example:"dd-mm-yyyy" extensions:"x-order=4"`
+}
+
+// Read loads user's settings. Users without saved settings get the defaults,
+// so a 404 from the database is not an error here.
+func (st *UserSettings) Read(dbw dbWorker) error {
+	params := map[string]any{"user_uuid": st.UserUUID}
+	err := dbw.ReadOneRecord(st, params)
+	if err != nil {
+		if err.Error() == "404" {
+			st.Timezone = defaultTimezone
+			st.Locale = defaultLocale
+			st.WeekStart = defaultWeekStart
+			st.DateFormat = defaultDateFormat
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Location returns user's time zone, UTC if it can't be loaded.
+func (st *UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DateLayout returns Go layout of user's date format.
+func (st *UserSettings) DateLayout() string {
+	layout, ok := dateFormats[st.DateFormat]
+	if !ok {
+		return dateFormats[defaultDateFormat]
+	}
+	return layout
+}
+
+func (u *updateUserSettings) validate() error {
+	if u.Timezone == "" {
+		return errors.New("timezone is required")
+	}
+	_, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return errors.New("unknown timezone")
+	}
+	if !localeRegexp.MatchString(u.Locale) {
+		return errors.New("locale has to look like en or en-US")
+	}
+	if u.WeekStart < 0 || u.WeekStart > 6 {
+		return errors.New("weekStart has to be between 0 (Sunday) and 6")
+	}
+	if _, ok := dateFormats[u.DateFormat]; !ok {
+		return errors.New("dateFormat has to be one of dd-mm-yyyy, dd.mm.yyyy, mm/dd/yyyy, yyyy-mm-dd")
+	}
+	return nil
+}
+
+func (u *updateUserSettings) Save(dbw dbWorker) error {
+	st := UserSettings{}
+	err := dbw.ReadOneRecord(&st, map[string]any{"user_uuid": u.UserUUID})
+	if err != nil {
+		if err.Error() != "404" {
+			return err
+		}
+		st = UserSettings{
+			UserUUID:   u.UserUUID,
+			Timezone:   u.Timezone,
+			Locale:     u.Locale,
+			WeekStart:  u.WeekStart,
+			DateFormat: u.DateFormat,
+		}
+		return dbw.CreateRecord(&st)
+	}
+
+	params := map[string]any{"user_uuid": u.UserUUID}
+	return dbw.UpdateRecordSubmodel(UserSettings{}, u, params)
+}
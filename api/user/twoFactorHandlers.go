@@ -0,0 +1,439 @@
+package user
+
+import (
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"time"
+	"todoApp/api/audit"
+	"todoApp/api/service"
+)
+
+// enrollTwoFactorFunc     godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Enroll two-factor authentication
+//	@Description	Creates a TOTP secret and returns it with an otpauth:// URI for authenticator apps. 2FA is off until confirmed with a code.
+//	@Tags			Two-factor
+//	@Produce		json
+//	@Success		200	{object}	enrollResponse			"OK"
+//	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		409	{object}	service.errorResponse	"Conflict"
+//	@Failure		500	{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/2fa/enroll [post]
+func enrollTwoFactorFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		usr := User{UserUUID: session.UserUuid}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
+		tf := TwoFactor{UserUUID: usr.UserUUID}
+		err = tf.Enroll(s.DbWorker)
+		if err != nil {
+			if err.Error() == "409" {
+				w.WriteHeader(http.StatusConflict)
+				log.Error(service.TwoFactorEnabledErr)
+				service.ConflictResponse(w, service.TwoFactorEnabledErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": usr.UserUUID,
+		}).Info(service.TwoFactorEnrolled)
+		service.OkResponse(w, enrollResponse{
+			Secret: tf.Secret,
+			URI:    totpURI(tf.Secret, usr.Email),
+		})
+	}
+}
+
+// confirmTwoFactorFunc     godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Confirm two-factor authentication
+//	@Description	Turns 2FA on with a code from the authenticator app. Returns recovery codes, they are shown only once.
+//	@Tags			Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		twoFactorCode			true	"TOTP code"
+//	@Success		200		{array}		string					"Recovery codes"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/2fa/confirm [post]
+func confirmTwoFactorFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		code := twoFactorCode{}
+		err = service.DeserializeJSON(data, &code)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		tf := TwoFactor{UserUUID: session.UserUuid}
+		err = tf.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+		if tf.Enabled {
+			w.WriteHeader(http.StatusConflict)
+			log.Error(service.TwoFactorEnabledErr)
+			service.ConflictResponse(w, service.TwoFactorEnabledErr)
+			return
+		}
+
+		ok, err := tf.Verify(s.DbWorker, code.Code, true)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.TwoFactorCodeErr)
+			service.BadRequestResponse(w, service.TwoFactorCodeErr, nil)
+			return
+		}
+
+		codes, err := newRecoveryCodes(s.DbWorker, session.UserUuid)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindUser,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionUpdate,
+		}, map[string]any{"twoFactor": false}, map[string]any{"twoFactor": true})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": session.UserUuid,
+		}).Info(service.TwoFactorEnabled)
+		service.OkResponse(w, codes)
+	}
+}
+
+// disableTwoFactorFunc     godoc
+//
+//	@Security		BasicAuth
+//	@Summary		Disable two-factor authentication
+//	@Description	Turns 2FA off. Needs a code from the authenticator app or a recovery code.
+//	@Tags			Two-factor
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		twoFactorCode			true	"TOTP or recovery code"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		404		{object}	service.errorResponse	"Not Found"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/me/2fa [delete]
+func disableTwoFactorFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token, err := service.ReadToken(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenReadErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		session, err := readSession(s, token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			log.Error(service.TokenValidationErr, err)
+			service.UnauthorizedResponse(w, "")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		code := twoFactorCode{}
+		err = service.DeserializeJSON(data, &code)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		tf := TwoFactor{UserUUID: session.UserUuid}
+		err = tf.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		ok, err := checkSecondFactor(s, &tf, code.Code)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.TwoFactorCodeErr)
+			service.BadRequestResponse(w, service.TwoFactorCodeErr, nil)
+			return
+		}
+
+		err = tf.Disable(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  session.UserUuid,
+			EntityKind: audit.KindUser,
+			EntityUUID: session.UserUuid,
+			Action:     audit.ActionUpdate,
+		}, map[string]any{"twoFactor": true}, map[string]any{"twoFactor": false})
+
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{
+			"id": session.UserUuid,
+		}).Info(service.TwoFactorDisabled)
+		service.OkResponse(w, service.DefaultResponse{
+			ResultCode: 0,
+			HttpCode:   http.StatusOK,
+			Messages:   service.TwoFactorDisabled,
+			Data:       nil,
+		})
+	}
+}
+
+// checkSecondFactor accepts a TOTP code or, failing that, a recovery code.
+func checkSecondFactor(s *Service, tf *TwoFactor, code string) (bool, error) {
+	if !tf.Enabled {
+		return false, nil
+	}
+	ok, err := tf.Verify(s.DbWorker, code, false)
+	if err != nil || ok {
+		return ok, err
+	}
+	return useRecoveryCode(s.DbWorker, tf.UserUUID, code)
+}
+
+// loginTwoFactorFunc     godoc
+//
+//	@Summary		Log in with second factor
+//	@Description	Exchanges the challenge token from /login and a TOTP or recovery code for a session cookie. A challenge lives 5 minutes and allows 5 attempts. Wrong codes count as failed logins of the account and the IP (429 with Retry-After).
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			data	body		loginTwoFactorModel		true	"Challenge and code"
+//	@Success		200		{object}	service.DefaultResponse	"OK"
+//	@Failure		400		{object}	service.errorResponse	"Bad request"
+//	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
+//	@Failure		429		{object}	service.errorResponse	"Too many requests"
+//	@Failure		500		{object}	service.errorResponse	"Internal server error"
+//	@Router			/login/2fa [post]
+func loginTwoFactorFunc(s *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.BodyReadErr, err)
+			service.BadRequestResponse(w, service.BodyReadErr, err)
+			return
+		}
+
+		l := loginTwoFactorModel{}
+		err = service.DeserializeJSON(data, &l)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			log.Error(service.JSONDeserializingErr, err)
+			service.UnprocessableEntityResponse(w, service.JSONDeserializingErr, err)
+			return
+		}
+
+		challenge := LoginChallenge{}
+		err = challenge.Find(s.DbWorker, l.ChallengeToken)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.Error(service.ChallengeErr)
+				service.UnauthorizedResponse(w, service.ChallengeErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		usr := User{UserUUID: challenge.UserUUID}
+		err = usr.Read(s.DbWorker)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
+		// Wrong codes count as failed logins, so a known password doesn't
+		// buy unlimited guesses through fresh challenges.
+		now := time.Now()
+		account := LoginFailure{Key: accountKey(usr.Email)}
+		ip := LoginFailure{Key: ipKey(r, s.Config.Config.TrustedProxyHeader)}
+		if claimLogin(w, s, &account, &ip, now) {
+			return
+		}
+
+		err = challenge.Spend(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.Error(service.ChallengeErr)
+				service.UnauthorizedResponse(w, service.ChallengeErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		tf := TwoFactor{UserUUID: challenge.UserUUID}
+		err = tf.Read(s.DbWorker)
+		if err != nil && err.Error() != "404" {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		ok, err := checkSecondFactor(s, &tf, l.Code)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+		if !ok {
+			warnLockedOut(s, &account, usr)
+			w.WriteHeader(http.StatusBadRequest)
+			log.WithFields(log.Fields{
+				"id": challenge.UserUUID,
+			}).Error(service.TwoFactorCodeErr)
+			service.BadRequestResponse(w, service.TwoFactorCodeErr, nil)
+			return
+		}
+
+		releaseLoginIP(s, &ip)
+
+		err = challenge.Use(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusUnauthorized)
+				log.Error(service.ChallengeErr)
+				service.UnauthorizedResponse(w, service.ChallengeErr)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.TwoFactorErr, err)
+			service.InternalServerErrorResponse(w, service.TwoFactorErr, err)
+			return
+		}
+
+		startSession(w, r, s, usr)
+	}
+}
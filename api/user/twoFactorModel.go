@@ -0,0 +1,247 @@
+package user
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	challengeTTL       = 5 * time.Minute
+	challengeAttempts  = 5
+)
+
+// TwoFactor is user's TOTP secret. It stays disabled until the user proves
+// with a code that the authenticator app got it.
+type TwoFactor struct {
+	ID        uint      `gorm:"primarykey"`
+	UserUUID  uuid.UUID `gorm:"uniqueIndex"`
+	Secret    string
+	Enabled   bool
+	LastStep  int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        uint      `gorm:"primarykey"`
+	UserUUID  uuid.UUID `gorm:"index"`
+	CodeHash  string    `gorm:"index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// LoginChallenge is issued after a correct password when 2FA is on. It is
+// exchanged for a session together with a valid code.
+type LoginChallenge struct {
+	ID        uint      `gorm:"primarykey"`
+	UserUUID  uuid.UUID `gorm:"index"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	Attempts  int
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type enrollResponse struct {
+	Secret string `json:"secret" extensions:"x-order=1"`
+	URI    string `json:"otpauthUri" extensions:"x-order=2"`
+}
+
+type twoFactorCode struct {
+	Code string `json:"code" example:"123456" extensions:"x-order=1"`
+}
+
+type challengeResponse struct {
+	TwoFactorRequired bool      `json:"twoFactorRequired" extensions:"x-order=1"`
+	ChallengeToken    string    `json:"challengeToken" extensions:"x-order=2"`
+	Expires           time.Time `json:"expires" extensions:"x-order=3"`
+}
+
+type loginTwoFactorModel struct {
+	ChallengeToken string `json:"challengeToken" extensions:"x-order=1"`
+	Code           string `json:"code" example:"123456" extensions:"x-order=2"`
+}
+
+type twoFactorUpdate struct {
+	Enabled  bool
+	LastStep int64
+}
+
+type challengeUsed struct {
+	UsedAt *time.Time
+}
+
+type recoveryCodeUsed struct {
+	UsedAt *time.Time
+}
+
+func (t *TwoFactor) Read(wrk dbWorker) error {
+	return wrk.ReadOneRecord(t, map[string]any{"user_uuid": t.UserUUID})
+}
+
+// IsEnabled reports whether the user has confirmed 2FA.
+func (t *TwoFactor) IsEnabled(wrk dbWorker) (bool, error) {
+	err := t.Read(wrk)
+	if err != nil {
+		if err.Error() == "404" {
+			return false, nil
+		}
+		return false, err
+	}
+	return t.Enabled, nil
+}
+
+// Enroll stores a new pending secret, replacing a previous unconfirmed one.
+func (t *TwoFactor) Enroll(wrk dbWorker) error {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return err
+	}
+
+	existing := TwoFactor{UserUUID: t.UserUUID}
+	err = existing.Read(wrk)
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+	if err == nil {
+		if existing.Enabled {
+			return errors.New("409")
+		}
+		err = wrk.DeleteRecord(&TwoFactor{}, map[string]any{"id": existing.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Secret = secret
+	t.Enabled = false
+	return wrk.CreateRecord(t)
+}
+
+// Verify checks a TOTP code and remembers its time step against replays.
+// The step is only written over an older one, so of two requests with the
+// same code only the first succeeds.
+func (t *TwoFactor) Verify(wrk dbWorker, code string, enable bool) (bool, error) {
+	step := verifyTOTP(t.Secret, code, time.Now(), t.LastStep)
+	if step == 0 {
+		return false, nil
+	}
+
+	upd := twoFactorUpdate{Enabled: t.Enabled || enable, LastStep: step}
+	err := wrk.UpdateRecordSubmodel(TwoFactor{}, &upd, map[string]any{"id": t.ID, "last_step <": step})
+	if err != nil {
+		if err.Error() == "404" {
+			return false, nil
+		}
+		return false, err
+	}
+	t.Enabled, t.LastStep = upd.Enabled, upd.LastStep
+	return true, nil
+}
+
+func (t *TwoFactor) Disable(wrk dbWorker) error {
+	err := wrk.DeleteRecord(&TwoFactor{}, map[string]any{"user_uuid": t.UserUUID})
+	if err != nil {
+		return err
+	}
+	err = wrk.DeleteRecord(&RecoveryCode{}, map[string]any{"user_uuid": t.UserUUID})
+	if err != nil && err.Error() != "404" {
+		return err
+	}
+	return nil
+}
+
+// newRecoveryCodes replaces user's recovery codes and returns the plain
+// ones. They are shown once, only hashes are kept.
+func newRecoveryCodes(wrk dbWorker, userUuid uuid.UUID) ([]string, error) {
+	err := wrk.DeleteRecord(&RecoveryCode{}, map[string]any{"user_uuid": userUuid})
+	if err != nil && err.Error() != "404" {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		key, err := generateEmailVerificationKey()
+		if err != nil {
+			return nil, err
+		}
+		code := key[:5] + "-" + key[5:10]
+
+		err = wrk.CreateRecord(&RecoveryCode{UserUUID: userUuid, CodeHash: hashKey(code)})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// useRecoveryCode burns a matching unused recovery code.
+func useRecoveryCode(wrk dbWorker, userUuid uuid.UUID, code string) (bool, error) {
+	now := time.Now()
+	params := map[string]any{
+		"user_uuid":  userUuid,
+		"code_hash":  hashKey(strings.ToLower(strings.TrimSpace(code))),
+		"used_at IS": nil,
+	}
+	err := wrk.UpdateRecordSubmodel(RecoveryCode{}, &recoveryCodeUsed{UsedAt: &now}, params)
+	if err != nil {
+		if err.Error() == "404" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Create issues a challenge for the user and returns its plain token.
+func (c *LoginChallenge) Create(wrk dbWorker) (string, error) {
+	token, err := generateEmailVerificationKey()
+	if err != nil {
+		return "", err
+	}
+
+	c.TokenHash = hashKey(token)
+	c.ExpiresAt = time.Now().Add(challengeTTL)
+
+	err = wrk.CreateRecord(c)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Find loads an unused, unexpired challenge that still has attempts left.
+func (c *LoginChallenge) Find(wrk dbWorker, token string) error {
+	params := map[string]any{"token_hash": hashKey(token), "used_at IS": nil}
+	err := wrk.ReadOneRecord(c, params)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(c.ExpiresAt) || c.Attempts >= challengeAttempts {
+		return errors.New("404")
+	}
+	return nil
+}
+
+// Spend takes one of the challenge's attempts before the code is checked.
+// The limit is part of the update, so parallel tries can't exceed it.
+func (c *LoginChallenge) Spend(wrk dbWorker) error {
+	params := map[string]any{
+		"id":           c.ID,
+		"used_at IS":   nil,
+		"attempts <":   challengeAttempts,
+		"expires_at >": time.Now(),
+	}
+	return wrk.Increment(LoginChallenge{}, "attempts", params)
+}
+
+// Use marks the challenge as passed, it can't be passed twice.
+func (c *LoginChallenge) Use(wrk dbWorker) error {
+	now := time.Now()
+	params := map[string]any{"id": c.ID, "used_at IS": nil}
+	return wrk.UpdateRecordSubmodel(LoginChallenge{}, &challengeUsed{UsedAt: &now}, params)
+}
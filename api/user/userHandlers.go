@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"todoApp/api/audit"
 	"todoApp/api/service"
+	"todoApp/types"
 )
 
 // createUserFunc     godoc
@@ -54,6 +56,14 @@ func createUserFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		violations := s.policy.Check(usr.Password, usr.Email, usr.Username)
+		if len(violations) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Error(service.PasswordPolicyErr, violations)
+			service.BadRequestDetailsResponse(w, service.PasswordPolicyErr, violations)
+			return
+		}
+
 		err = usr.emailExists(s.DbWorker)
 		if err != nil {
 			w.WriteHeader(http.StatusConflict)
@@ -90,6 +100,13 @@ func createUserFunc(s *Service) http.HandlerFunc {
 		}
 		log.Debug("Verification link sent on create user")
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  usr.UserUUID,
+			EntityKind: audit.KindUser,
+			EntityUUID: usr.UserUUID,
+			Action:     audit.ActionCreate,
+		}, nil, usr)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id":       usr.UserUUID,
@@ -111,6 +128,7 @@ func createUserFunc(s *Service) http.HandlerFunc {
 //	@Success		200	{object}	User					"OK"
 //	@Failure		400	{object}	service.errorResponse	"Bad request"
 //	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403	{object}	service.errorResponse	"Forbidden"
 //	@Failure		404	{object}	service.errorResponse	"Not Found"
 //	@Failure		500	{object}	service.errorResponse	"Internal Server Error"
 //	@Router			/user/{id} [get]
@@ -118,7 +136,10 @@ func readUserFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		target := targetUUID(w, r, s)
+		target, _ := targetUUID(w, r, s)
+		if target == uuid.Nil {
+			return
+		}
 
 		usr := readUser{UserUUID: target}
 		err := usr.Read(s.DbWorker)
@@ -158,6 +179,7 @@ func readUserFunc(s *Service) http.HandlerFunc {
 //	@Success		200		{object}	service.DefaultResponse	"OK"
 //	@Failure		400		{object}	service.errorResponse	"Bad request"
 //	@Failure		401		{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403		{object}	service.errorResponse	"Forbidden"
 //	@Failure		404		{object}	service.errorResponse	"Not Found"
 //	@Failure		422		{object}	service.errorResponse	"Unprocessable entity"
 //	@Failure		500		{object}	service.errorResponse	"Internal server error"
@@ -165,7 +187,10 @@ func readUserFunc(s *Service) http.HandlerFunc {
 func updateUserFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		target := targetUUID(w, r, s)
+		target, actor := targetUUID(w, r, s)
+		if target == uuid.Nil {
+			return
+		}
 		usr := updateUser{UserUUID: target}
 
 		data, err := io.ReadAll(r.Body)
@@ -186,6 +211,21 @@ func updateUserFunc(s *Service) http.HandlerFunc {
 
 		usr.Email = strings.ToLower(usr.Email)
 
+		before := User{UserUUID: target}
+		err = before.Read(s.DbWorker)
+		if err != nil {
+			if err.Error() == "404" {
+				w.WriteHeader(http.StatusNotFound)
+				log.Error(service.DBNotFound)
+				service.NotFoundResponse(w, "")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Error(service.UserReadErr, err)
+			service.InternalServerErrorResponse(w, service.UserReadErr, err)
+			return
+		}
+
 		err = usr.Update(s.DbWorker)
 		if err != nil {
 			if err.Error() == "404" {
@@ -204,6 +244,19 @@ func updateUserFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		after := User{UserUUID: target}
+		err = after.Read(s.DbWorker)
+		if err != nil {
+			log.Error(service.UserReadErr, err)
+		}
+
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  actor,
+			EntityKind: audit.KindUser,
+			EntityUUID: target,
+			Action:     audit.ActionUpdate,
+		}, before, after)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id":       usr.UserUUID,
@@ -232,6 +285,7 @@ func updateUserFunc(s *Service) http.HandlerFunc {
 //	@Success		200	{object}	service.DefaultResponse	"OK"
 //	@Failure		400	{object}	service.errorResponse	"Bad request"
 //	@Failure		401	{object}	service.errorResponse	"Unauthorized"
+//	@Failure		403	{object}	service.errorResponse	"Forbidden"
 //	@Failure		404	{object}	service.errorResponse	"Not Found"
 //	@Failure		500	{object}	service.errorResponse	"Internal Server Error"
 //	@Router			/user/{id} [delete]
@@ -239,10 +293,16 @@ func deleteUserFunc(s *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		target := targetUUID(w, r, s)
+		target, actor := targetUUID(w, r, s)
+		if target == uuid.Nil {
+			return
+		}
 		usr := User{UserUUID: target}
 
-		err := usr.Delete(s.DbWorker)
+		err := usr.Read(s.DbWorker)
+		if err == nil {
+			err = usr.Delete(s.DbWorker)
+		}
 		if err != nil {
 			if err.Error() == "404" {
 				w.WriteHeader(http.StatusNotFound)
@@ -256,6 +316,13 @@ func deleteUserFunc(s *Service) http.HandlerFunc {
 			return
 		}
 
+		audit.Record(s.DbWorker, r, audit.Event{
+			ActorUUID:  actor,
+			EntityKind: audit.KindUser,
+			EntityUUID: target,
+			Action:     audit.ActionDelete,
+		}, usr, nil)
+
 		w.WriteHeader(http.StatusOK)
 		log.WithFields(log.Fields{
 			"id": usr.UserUUID,
@@ -270,46 +337,63 @@ func deleteUserFunc(s *Service) http.HandlerFunc {
 	}
 }
 
-func targetUUID(w http.ResponseWriter, r *http.Request, s *Service) uuid.UUID {
-	token, err := r.Cookie(service.SessionTokenName)
+// targetUUID returns the user the request is about and the user who makes it.
+// They differ only when the caller passes someone else's UUID, which needs
+// the users:read permission to read and users:write to change. On failure it
+// answers the request itself and returns uuid.Nil.
+func targetUUID(w http.ResponseWriter, r *http.Request, s *Service) (uuid.UUID, uuid.UUID) {
+	token, err := service.ReadToken(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		log.Error(service.TokenReadErr, err.Error())
 		service.BadRequestResponse(w, service.CookieReadErr, err)
-		return uuid.Nil
+		return uuid.Nil, uuid.Nil
 	}
 
-	session := Session{Token: token.Value}
-	err = session.Read(s.DbWorker)
+	authUser, err := s.AuthWorker.IsUserLoggedIn(s.DbWorker, token)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		log.Error(service.TokenValidationErr, err)
 		service.UnauthorizedResponse(w, "")
-		return uuid.Nil
+		return uuid.Nil, uuid.Nil
 	}
 
-	id := r.PathValue("id")
-	parsedUUID := uuid.Nil
+	write := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if !authUser.Allows("", write) {
+		w.WriteHeader(http.StatusForbidden)
+		log.Error(service.TokenScopeErr)
+		service.ForbiddenResponse(w, service.TokenScopeErr)
+		return uuid.Nil, uuid.Nil
+	}
 
-	if id != "" {
-		parsedUUID, err = uuid.Parse(id)
-		if err != nil {
-			log.Warning(service.UUIDParseErr, err, ", ignoring")
-		}
+	id := r.PathValue("id")
+	if id == "" {
+		return authUser.UserUUID, authUser.UserUUID
 	}
 
-	usr := User{UserUUID: session.UserUuid}
-	err = usr.Read(s.DbWorker)
+	parsedUUID, err := uuid.Parse(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Error(service.UserReadErr, err.Error())
-		service.InternalServerErrorResponse(w, service.UserReadErr, err)
-		return uuid.Nil
+		log.Warning(service.UUIDParseErr, err, ", ignoring")
+		return authUser.UserUUID, authUser.UserUUID
+	}
+	if parsedUUID == authUser.UserUUID {
+		return authUser.UserUUID, authUser.UserUUID
 	}
 
-	if usr.IsSuperuser && parsedUUID != uuid.Nil {
-		return parsedUUID
+	permission := types.PermUsersRead
+	if write {
+		permission = types.PermUsersWrite
+	}
+	if !authUser.Can(permission) {
+		w.WriteHeader(http.StatusForbidden)
+		log.WithFields(log.Fields{
+			"id":         authUser.UserUUID,
+			"target":     parsedUUID,
+			"permission": permission,
+		}).Error(service.Forbidden)
+		service.ForbiddenResponse(w, service.PermissionErr)
+		return uuid.Nil, uuid.Nil
 	}
 
-	return session.UserUuid
+	return parsedUUID, authUser.UserUUID
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
+	"todoApp/types"
 )
 
 type User struct {
@@ -20,6 +21,7 @@ type User struct {
 	Surname              string    `json:"surname" extensions:"x-order=5"`
 	UserUUID             uuid.UUID `json:"-" gorm:"index"`
 	IsSuperuser          bool      `json:"-"`
+	Role                 string    `json:"-" gorm:"default:user"`
 }
 
 type readUser struct {
@@ -61,6 +63,7 @@ func (u *User) Create(wrk dbWorker) error {
 	var err error
 	u.UserUUID = uuid.New()
 	u.IsSuperuser = false
+	u.Role = types.RoleUser
 
 	u.Password, err = hashPassword(u.Password)
 	if err != nil {
@@ -126,15 +129,44 @@ func (u *updateUser) Update(wrk dbWorker) error {
 	return nil
 }
 
-func (u *User) Delete(wrk dbWorker) error {
+type passwordUpdate struct {
+	Password string
+}
+
+// SetPassword hashes and saves the new password.
+func (u *User) SetPassword(wrk dbWorker, password string) error {
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	params := map[string]any{"user_uuid": u.UserUUID}
-	err := wrk.DeleteRecord(u, params)
+	err = wrk.UpdateRecordSubmodel(User{}, &passwordUpdate{Password: hashed}, params)
 	if err != nil {
 		return err
 	}
+	u.Password = hashed
 	return nil
 }
 
+// Delete removes the user together with the external identities linked to
+// it, so the next login with them starts over.
+func (u *User) Delete(wrk dbWorker) error {
+	return wrk.Transaction(func(tx types.DatabaseWorker) error {
+		params := map[string]any{"user_uuid": u.UserUUID}
+		err := tx.DeleteRecord(u, params)
+		if err != nil {
+			return err
+		}
+
+		err = tx.DeleteRecord(&ExternalIdentity{}, params)
+		if err != nil && err.Error() != "404" {
+			return err
+		}
+		return nil
+	})
+}
+
 func (m *meModel) Read(wrk dbWorker) error {
 	params := map[string]any{"user_uuid": m.UserUUID}
 	params["model"] = User{}
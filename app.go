@@ -3,6 +3,9 @@ package main
 import (
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"todoApp/api/audit"
+	"todoApp/api/calendar"
+	"todoApp/api/notifications"
 	"todoApp/api/todoList"
 	"todoApp/api/user"
 	"todoApp/config"
@@ -21,6 +24,13 @@ type todoApp struct {
 }
 
 func (t *todoApp) Init() error {
+	audit.Init(&audit.Service{
+		DbWorker:   t.dbWorker,
+		AuthWorker: t.authWorker,
+		Router:     t.router,
+		Config:     t.config,
+	})
+
 	user.Init(&user.Service{
 		DbWorker:   t.dbWorker,
 		AuthWorker: t.authWorker,
@@ -35,6 +45,20 @@ func (t *todoApp) Init() error {
 		Router:     t.router,
 	})
 
+	notifications.Init(&notifications.Service{
+		DbWorker:   t.dbWorker,
+		AuthWorker: t.authWorker,
+		Router:     t.router,
+		Config:     t.config,
+	})
+
+	calendar.Init(&calendar.Service{
+		DbWorker:   t.dbWorker,
+		AuthWorker: t.authWorker,
+		Router:     t.router,
+		Config:     t.config,
+	})
+
 	infoPages.Init(&infoPages.Service{
 		Router: t.router,
 	})
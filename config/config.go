@@ -13,14 +13,46 @@ type EnvFileConfig struct {
 	Dbname       string
 	Sslmode      string
 	DomainName   string
+	ResetURL     string
+	UnlockURL    string
+	ApiURL       string
 	HTTPHost     string
 	HTTPPort     string
 	AppLogLevel  string
+	LogTimeFmt   string
 	DBLogLevel   string
 	EmailService string
 	EmailLogin   string
 	EmailPass    string
 	EmailReply   string
+
+	ReminderInterval string
+	ReminderLeadTime string
+	DigestInterval   string
+
+	PasswordMinLength     string
+	PasswordClasses       string
+	PasswordCheckPersonal string
+	PasswordBreachedFile  string
+
+	AuthMode        string
+	JWTKeys         string
+	JWTAccessTTL    string
+	RefreshTokenTTL string
+
+	OIDCProviders   []OIDCProviderConfig
+	OIDCRedirectURL string
+
+	TrustedProxyHeader string
+}
+
+// OIDCProviderConfig is an external OpenID Connect provider users can sign in
+// with. Name is used in the login URL.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
 }
 
 type CORSConfig struct {
@@ -40,14 +72,37 @@ func New() *Config {
 		Dbname:       getEnv("DB_NAME"),
 		Sslmode:      getEnv("DB_SSLMODE"),
 		DomainName:   getEnv("DOMAIN_NAME"),
+		ResetURL:     getEnv("RESET_PASSWORD_URL"),
+		UnlockURL:    getEnv("UNLOCK_ACCOUNT_URL"),
+		ApiURL:       getEnv("API_URL"),
 		HTTPHost:     getEnv("HTTP_HOST"),
 		HTTPPort:     getEnv("HTTP_PORT"),
 		AppLogLevel:  getEnv("APP_LOG_LEVEL"),
+		LogTimeFmt:   getEnv("LOG_TIME_FORMAT"),
 		DBLogLevel:   getEnv("DB_LOG_LEVEL"),
 		EmailService: getEnv("EMAIL_SERVICE"),
 		EmailLogin:   getEnv("EMAIL_LOGIN"),
 		EmailPass:    getEnv("EMAIL_PASS"),
 		EmailReply:   getEnv("EMAIL_REPLY"),
+
+		ReminderInterval: getEnv("REMINDER_INTERVAL"),
+		ReminderLeadTime: getEnv("REMINDER_LEAD_TIME"),
+		DigestInterval:   getEnv("DIGEST_INTERVAL"),
+
+		PasswordMinLength:     getEnv("PASSWORD_MIN_LENGTH"),
+		PasswordClasses:       getEnv("PASSWORD_CLASSES"),
+		PasswordCheckPersonal: getEnv("PASSWORD_CHECK_PERSONAL"),
+		PasswordBreachedFile:  getEnv("PASSWORD_BREACHED_FILE"),
+
+		AuthMode:        getEnv("AUTH_MODE"),
+		JWTKeys:         getEnv("JWT_KEYS"),
+		JWTAccessTTL:    getEnv("JWT_ACCESS_TTL"),
+		RefreshTokenTTL: getEnv("REFRESH_TOKEN_TTL"),
+
+		OIDCProviders:   oidcProviders(),
+		OIDCRedirectURL: getEnv("OIDC_REDIRECT_URL"),
+
+		TrustedProxyHeader: getEnv("TRUSTED_PROXY_HEADER"),
 	}}
 }
 
@@ -64,6 +119,28 @@ func CorsConfig() *CORSConfig {
 	}
 }
 
+// oidcProviders reads OIDC_PROVIDERS, a comma separated list of names, and
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET
+// for each of them.
+func oidcProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getEnv(prefix + "ISSUER"),
+			ClientID:     getEnv(prefix + "CLIENT_ID"),
+			ClientSecret: getEnv(prefix + "CLIENT_SECRET"),
+		})
+	}
+	return providers
+}
+
 func getEnv(key string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
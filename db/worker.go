@@ -4,10 +4,23 @@ import (
 	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"strings"
 	"time"
+	"todoApp/types"
 )
 
+// whereClause turns a params key into a condition. Plain column names are
+// compared for equality, keys that already carry an operator ("deadline <=",
+// "status IN") are used as is.
+func whereClause(key string) string {
+	if strings.Contains(strings.TrimSpace(key), " ") {
+		return fmt.Sprintf("%s ?", key)
+	}
+	return fmt.Sprintf("%s = ?", key)
+}
+
 func (db *DB) InitTable(model any) error {
 	err := db.Connection.AutoMigrate(model)
 	if err != nil {
@@ -25,6 +38,21 @@ func (db *DB) CreateRecord(model any) error {
 	return nil
 }
 
+// CreateIfMissing inserts the record unless it would break a unique index,
+// in which case the existing row is left as is and "409" is returned.
+func (db *DB) CreateIfMissing(model any) error {
+	result := db.Connection.Clauses(clause.OnConflict{DoNothing: true}).Create(model)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("409")
+	}
+	return nil
+}
+
 func (db *DB) ReadOneRecord(model any, params map[string]any) error {
 	query := db.Connection
 
@@ -32,8 +60,11 @@ func (db *DB) ReadOneRecord(model any, params map[string]any) error {
 		switch key {
 		case "model":
 			query = query.Model(params["model"])
+		case "lock":
+			// Locks the row until the surrounding transaction ends.
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
 		default:
-			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+			query = query.Where(whereClause(key), value)
 		}
 	}
 
@@ -53,7 +84,7 @@ func (db *DB) ReadRecordSubmodel(model any, submodel any, params map[string]any)
 	query := db.Connection.Model(model)
 
 	for key, value := range params {
-		query = query.Where(fmt.Sprintf("%s = ?", key), value)
+		query = query.Where(whereClause(key), value)
 	}
 
 	result := query.First(submodel)
@@ -83,7 +114,7 @@ func (db *DB) ReadManyRecords(model any, submodel any, params map[string]any) er
 			continue
 
 		default:
-			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+			query = query.Where(whereClause(key), value)
 		}
 	}
 
@@ -107,7 +138,7 @@ func (db *DB) ReadWithPagination(model any, params map[string]any) error {
 		switch key {
 		case "page":
 			{
-				query = query.Offset((params["page"]).(int) - 1*params["count"].(int))
+				query = query.Offset((params["page"].(int) - 1) * params["count"].(int))
 				log.WithFields(log.Fields{
 					"page": params["page"],
 				}).Debug(debugLogHeader)
@@ -130,7 +161,7 @@ func (db *DB) ReadWithPagination(model any, params map[string]any) error {
 			continue
 
 		default:
-			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+			query = query.Where(whereClause(key), value)
 			log.WithFields(log.Fields{
 				key: value,
 			}).Debug(debugLogHeader)
@@ -152,7 +183,7 @@ func (db *DB) UpdateRecord(model any, params map[string]any) error {
 	query := db.Connection
 
 	for k, v := range params {
-		query = query.Where(fmt.Sprintf("%s = ?", k), v)
+		query = query.Where(whereClause(k), v)
 	}
 	result := query.Updates(model)
 
@@ -170,7 +201,7 @@ func (db *DB) UpdateRecordSubmodel(model any, submodel any, params map[string]an
 	query := db.Connection.Model(model)
 
 	for k, v := range params {
-		query = query.Where(fmt.Sprintf("%s = ?", k), v)
+		query = query.Where(whereClause(k), v)
 	}
 
 	result := query.Select("*").Updates(submodel)
@@ -185,11 +216,32 @@ func (db *DB) UpdateRecordSubmodel(model any, submodel any, params map[string]an
 	return nil
 }
 
+// Increment adds one to column in a single statement, so concurrent callers
+// never lose an update. Conditions in params can guard a limit.
+func (db *DB) Increment(model any, column string, params map[string]any) error {
+	query := db.Connection.Model(model)
+
+	for k, v := range params {
+		query = query.Where(whereClause(k), v)
+	}
+
+	result := query.UpdateColumn(column, gorm.Expr(column+" + ?", 1))
+
+	if result.RowsAffected == 0 {
+		return errors.New("404")
+	}
+
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
 func (db *DB) DeleteRecord(model any, params map[string]any) error {
 	query := db.Connection
 
 	for k, v := range params {
-		query = query.Where(fmt.Sprintf("%s = ?", k), v)
+		query = query.Where(whereClause(k), v)
 	}
 
 	result := query.Delete(model)
@@ -204,9 +256,16 @@ func (db *DB) DeleteRecord(model any, params map[string]any) error {
 	return nil
 }
 
+// DeleteManyExceptOne ends the user's sessions except the one with the given
+// token, or, when params hold a "family", except all rows of that family.
 func (db *DB) DeleteManyExceptOne(model any, params map[string]any) error {
 	query := `UPDATE sessions SET deleted_at = ? WHERE user_uuid = ? AND token != ? AND deleted_at IS NULL`
-	result := db.Connection.Exec(query, time.Now(), params["user_uuid"], params["token"])
+	except := params["token"]
+	if family, ok := params["family"]; ok {
+		query = `UPDATE sessions SET deleted_at = ? WHERE user_uuid = ? AND family IS DISTINCT FROM ? AND deleted_at IS NULL`
+		except = family
+	}
+	result := db.Connection.Exec(query, time.Now(), params["user_uuid"], except)
 
 	if result.RowsAffected == 0 {
 		return errors.New("404")
@@ -217,3 +276,37 @@ func (db *DB) DeleteManyExceptOne(model any, params map[string]any) error {
 	}
 	return nil
 }
+
+// Transaction runs fn with a worker bound to a single database transaction.
+// Returning an error from fn rolls everything back.
+func (db *DB) Transaction(fn func(tx types.DatabaseWorker) error) error {
+	return db.Connection.Transaction(func(tx *gorm.DB) error {
+		return fn(&DB{Connection: tx})
+	})
+}
+
+// Aggregate scans the result of an aggregate query into dest. Besides the
+// usual filters params take raw "select", "group" and "order" clauses.
+func (db *DB) Aggregate(model any, dest any, params map[string]any) error {
+	query := db.Connection.Model(model)
+
+	for key, value := range params {
+		switch key {
+		case "select":
+			query = query.Select(value.(string))
+		case "group":
+			query = query.Group(value.(string))
+		case "order":
+			query = query.Order(value.(string))
+		default:
+			query = query.Where(whereClause(key), value)
+		}
+	}
+
+	result := query.Scan(dest)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
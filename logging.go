@@ -25,3 +25,11 @@ func appSetLogLevel(level string) log.Level {
 		return log.InfoLevel
 	}
 }
+
+// appLogTimeFormat returns Go layout for log timestamps.
+func appLogTimeFormat(format string) string {
+	if format == "" {
+		return "02-01-2006 15:04:05"
+	}
+	return format
+}
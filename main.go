@@ -29,6 +29,11 @@ import (
 // @in							header
 // @name						token
 //
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				"Bearer <token>" with a session token or a personal access token
+//
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
 func main() {
@@ -41,16 +46,21 @@ func main() {
 	log.SetLevel(appSetLogLevel(c.Config.AppLogLevel))
 	log.SetFormatter(&log.TextFormatter{
 		FullTimestamp:   true,
-		TimestampFormat: "02-01-2006 15:04:05",
+		TimestampFormat: appLogTimeFormat(c.Config.LogTimeFmt),
 		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
 			filename := path.Base(f.File)
 			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf(" %s:%d", filename, f.Line)
 		},
 	})
 
+	authWorker, err := user.NewAuthService(c)
+	if err != nil {
+		log.WithError(err).Fatal("Error reading auth config")
+	}
+
 	app := todoApp{
 		dbWorker:   &db.DB{Connection: db.Connect(c)},
-		authWorker: &user.AuthService{},
+		authWorker: authWorker,
 		salt:       []byte("hglI##ERgf9D)9e5v_*ZqS=H4JN9fFAu"),
 		server:     NewApiServer(c.Config.HTTPHost, c.Config.HTTPPort),
 		router:     http.NewServeMux(),
@@ -2,8 +2,48 @@ package types
 
 import (
 	"github.com/google/uuid"
+	"slices"
+	"strings"
 )
 
+// Scopes of personal access tokens. Sessions are not scoped.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Resources OAuth clients get scopes for, e.g. "lists:read" or
+// "tasks:write". Everything else is out of reach of OAuth tokens.
+const (
+	ResourceLists = "lists"
+	ResourceTasks = "tasks"
+)
+
+// Roles a user can have. Every user has RoleUser unless an admin assigns
+// another one.
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleUser    = "user"
+)
+
+// Permission is an action beyond the user's own data.
+type Permission string
+
+const (
+	PermUsersRead   Permission = "users:read"
+	PermUsersWrite  Permission = "users:write"
+	PermRolesAssign Permission = "roles:assign"
+	PermAuditRead   Permission = "audit:read"
+)
+
+// RolePermissions lists what each role may do.
+var RolePermissions = map[string][]Permission{
+	RoleAdmin:   {PermUsersRead, PermUsersWrite, PermRolesAssign, PermAuditRead},
+	RoleSupport: {PermUsersRead, PermAuditRead},
+	RoleUser:    {},
+}
+
 type AuthWorker interface {
 	IsUserLoggedIn(wrk DatabaseWorker, tokenValue string) (AuthUser, error)
 }
@@ -11,4 +51,39 @@ type AuthWorker interface {
 type AuthUser struct {
 	UserUUID    uuid.UUID
 	IsSuperuser bool
+	Role        string
+	// Scopes limit what a token may do, nil means full access.
+	Scopes []string
+}
+
+// Allows reports whether the credentials may read or, with write, change
+// the resource. Pass an empty resource for anything but lists and tasks.
+func (a AuthUser) Allows(resource string, write bool) bool {
+	if a.Scopes == nil {
+		return true
+	}
+
+	for _, scope := range a.Scopes {
+		res, access, found := strings.Cut(scope, ":")
+		if !found {
+			res, access = resource, scope
+		}
+		if res != resource {
+			continue
+		}
+		if access == ScopeWrite || (access == ScopeRead && !write) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the user's role grants the permission. Superusers
+// predate roles and count as admins.
+func (a AuthUser) Can(p Permission) bool {
+	role := a.Role
+	if a.IsSuperuser {
+		role = RoleAdmin
+	}
+	return slices.Contains(RolePermissions[role], p)
 }
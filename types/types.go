@@ -4,15 +4,20 @@ type DatabaseWorker interface {
 	InitTable(model any) error
 
 	CreateRecord(model any) error
+	CreateIfMissing(model any) error
 
 	ReadOneRecord(model any, params map[string]any) error
 	ReadRecordSubmodel(model any, submodel any, params map[string]any) error
 	ReadManyRecords(model any, submodel any, params map[string]any) error
 	ReadWithPagination(model any, params map[string]any) error
+	Aggregate(model any, dest any, params map[string]any) error
 
 	UpdateRecord(model any, params map[string]any) error
 	UpdateRecordSubmodel(model any, submodel any, params map[string]any) error
+	Increment(model any, column string, params map[string]any) error
 
 	DeleteRecord(model any, params map[string]any) error
 	DeleteManyExceptOne(model any, params map[string]any) error
+
+	Transaction(fn func(tx DatabaseWorker) error) error
 }